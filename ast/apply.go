@@ -0,0 +1,392 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+import (
+	"fmt"
+)
+
+// ApplyFunc is invoked by Apply for each node, before and/or after the node's
+// children are traversed.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses an AST in depth-first order like Walk, and returns the
+// resulting AST.  Since nodes are values, the original AST is not modified;
+// the changed nodes and their ancestors are copies.
+//
+// If pre is not nil, it is called for each node before the node's children are
+// traversed.  If pre returns false, no children are traversed, and post is not
+// called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false or pre is
+// nil, post is called for each node after its children are traversed.  If post
+// returns false, traversal is terminated and Apply returns immediately; the
+// changes made so far are included in the result.
+//
+// Nodes inserted using InsertBefore or InsertAfter are not traversed.  The
+// replacement node set using Replace is traversed instead of the original one
+// if pre made the replacement.
+func Apply(root Node, pre, post ApplyFunc) Node {
+	a := application{pre: pre, post: post}
+	return a.apply(nil, "", nil, root)
+}
+
+// Cursor describes a node encountered during Apply.
+type Cursor struct {
+	parent Node
+	name   string
+	iter   *iterator // Nil if not in a list.
+	node   Node
+}
+
+// iterator collects the changes made to a node in a list.
+type iterator struct {
+	index   int
+	deleted bool
+	before  []Node
+	after   []Node
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the parent of the current node.  It's a copy which doesn't
+// reflect changes made to the current node or its later siblings.
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Name returns the name of the parent node field that contains the current
+// node.  If the parent is a list, Name returns the name of the list field.
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns the index of the current node in the original list, or a value
+// < 0 if the current node is not part of a list.
+func (c *Cursor) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+// Replace the current node with n.  The replacement must have the type
+// required by the parent field; Apply panics otherwise.  n may be nil if the
+// field is optional.  The children of a nil replacement are not traversed.
+func (c *Cursor) Replace(n Node) {
+	c.node = n
+}
+
+// Delete the current node from its containing list.  If the current node is
+// not part of a list, Delete panics.  If pre deletes the node, its children
+// are not traversed.
+func (c *Cursor) Delete() {
+	c.list("Delete").deleted = true
+}
+
+// InsertBefore inserts n before the current node in its containing list.  If
+// the current node is not part of a list, InsertBefore panics.
+func (c *Cursor) InsertBefore(n Node) {
+	iter := c.list("InsertBefore")
+	iter.before = append(iter.before, n)
+}
+
+// InsertAfter inserts n after the current node in its containing list.  If the
+// current node is not part of a list, InsertAfter panics.  Multiple nodes are
+// inserted in call order.
+func (c *Cursor) InsertAfter(n Node) {
+	iter := c.list("InsertAfter")
+	iter.after = append(iter.after, n)
+}
+
+func (c *Cursor) list(method string) *iterator {
+	if c.iter == nil {
+		panic(method + " of node which is not part of a list")
+	}
+	return c.iter
+}
+
+type application struct {
+	pre     ApplyFunc
+	post    ApplyFunc
+	stopped bool
+}
+
+func (a *application) apply(parent Node, name string, iter *iterator, node Node) Node {
+	if a.stopped {
+		return node
+	}
+
+	c := Cursor{parent, name, iter, node}
+
+	if a.pre != nil && !a.pre(&c) {
+		return c.node
+	}
+	if (iter != nil && iter.deleted) || c.node == nil {
+		return c.node
+	}
+
+	c.node = a.applyChildren(c.node)
+
+	if !a.stopped && a.post != nil && !a.post(&c) {
+		a.stopped = true
+	}
+
+	return c.node
+}
+
+func applyChild[T Node](a *application, parent Node, name string, node T) T {
+	return convert[T](parent, name, a.apply(parent, name, nil, node))
+}
+
+// applyOptional is like applyChild, but node may be nil, and it may be
+// replaced with nil.
+func applyOptional[T Node](a *application, parent Node, name string, node T) T {
+	if Node(node) == nil {
+		return node
+	}
+	if n := a.apply(parent, name, nil, node); n != nil {
+		return convert[T](parent, name, n)
+	}
+	var zero T
+	return zero
+}
+
+// applyPointer is like applyOptional, but for a field which points to a node.
+func applyPointer[T Node](a *application, parent Node, name string, node *T) *T {
+	if node == nil {
+		return nil
+	}
+	if n := a.apply(parent, name, nil, *node); n != nil {
+		x := convert[T](parent, name, n)
+		return &x
+	}
+	return nil
+}
+
+func applyList[T Node](a *application, parent Node, name string, nodes []T) []T {
+	if len(nodes) == 0 {
+		return nodes
+	}
+
+	result := make([]T, 0, len(nodes))
+
+	for i, node := range nodes {
+		iter := iterator{index: i}
+		node := a.apply(parent, name, &iter, node)
+
+		for _, n := range iter.before {
+			result = append(result, convert[T](parent, name, n))
+		}
+		if !iter.deleted {
+			result = append(result, convert[T](parent, name, node))
+		}
+		for _, n := range iter.after {
+			result = append(result, convert[T](parent, name, n))
+		}
+	}
+
+	return result
+}
+
+// convert panics if n doesn't have the type required by the parent field.
+func convert[T Node](parent Node, name string, n Node) T {
+	x, ok := n.(T)
+	if !ok {
+		what := "nil"
+		if n != nil {
+			what = n.Node()
+		}
+		panic(fmt.Sprintf("%s field %s cannot hold %s", parent.Node(), name, what))
+	}
+	return x
+}
+
+func (a *application) applyChildren(node Node) Node {
+	switch n := node.(type) {
+	// File
+
+	case BadDecl, Comment:
+
+	case ConstantDef:
+		n.Value = applyChild(a, n, "Value", n.Value)
+		node = n
+
+	case EnumDef:
+		n.Members = applyList(a, n, "Members", n.Members)
+		node = n
+
+	case EnumMember:
+		n.Value = applyOptional(a, n, "Value", n.Value)
+		node = n
+
+	case Field:
+		n.Type = applyChild(a, n, "Type", n.Type)
+		node = n
+
+	case FunctionDef:
+		n.ReceiverType = applyPointer(a, n, "ReceiverType", n.ReceiverType)
+		n.Params = applyList(a, n, "Params", n.Params)
+		n.Results = applyList(a, n, "Results", n.Results)
+		n.Body = applyList(a, n, "Body", n.Body)
+		node = n
+
+	case Identifier:
+
+	case Import:
+		n.Names = applyList(a, n, "Names", n.Names)
+		node = n
+
+	case Imports:
+		n.Imports = applyList(a, n, "Imports", n.Imports)
+		node = n
+
+	case InterfaceDef:
+		n.Methods = applyList(a, n, "Methods", n.Methods)
+		node = n
+
+	case MethodSig:
+		n.Params = applyList(a, n, "Params", n.Params)
+		n.Results = applyList(a, n, "Results", n.Results)
+		node = n
+
+	case Parameter:
+		n.Type = applyChild(a, n, "Type", n.Type)
+		node = n
+
+	case TypeDef:
+		n.Fields = applyList(a, n, "Fields", n.Fields)
+		node = n
+
+	case TypeSpec:
+
+	// Statements
+
+	case Assign:
+		n.Targets = applyList(a, n, "Targets", n.Targets)
+		n.Values = applyList(a, n, "Values", n.Values)
+		node = n
+
+	case BadStmt, Break, Continue:
+
+	case Block:
+		n.Body = applyList(a, n, "Body", n.Body)
+		node = n
+
+	case Case:
+		n.Values = applyList(a, n, "Values", n.Values)
+		n.Body = applyList(a, n, "Body", n.Body)
+		node = n
+
+	case Defer:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case Expression:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case For:
+		n.Init = applyOptional(a, n, "Init", n.Init)
+		n.Test = applyOptional(a, n, "Test", n.Test)
+		n.Post = applyOptional(a, n, "Post", n.Post)
+		n.Range = applyPointer(a, n, "Range", n.Range)
+		n.Body = applyList(a, n, "Body", n.Body)
+		node = n
+
+	case ForRange:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case If:
+		n.Test = applyChild(a, n, "Test", n.Test)
+		n.Then = applyList(a, n, "Then", n.Then)
+		n.Else = applyList(a, n, "Else", n.Else)
+		node = n
+
+	case Return:
+		n.Values = applyList(a, n, "Values", n.Values)
+		node = n
+
+	case Switch:
+		n.Value = applyChild(a, n, "Value", n.Value)
+		n.Cases = applyList(a, n, "Cases", n.Cases)
+		node = n
+
+	case VariableDecl:
+		n.Type = applyPointer(a, n, "Type", n.Type)
+		node = n
+
+	case VariableDef:
+		n.Values = applyList(a, n, "Values", n.Values)
+		node = n
+
+	// Expressions
+
+	case Address:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case AssignerDereference:
+
+	case Binary:
+		n.Left = applyChild(a, n, "Left", n.Left)
+		n.Right = applyChild(a, n, "Right", n.Right)
+		node = n
+
+	case Boolean, Character, Empty, Float, Integer, Nil, Selector, String:
+
+	case Call:
+		n.Func = applyChild(a, n, "Func", n.Func)
+		n.Args = applyList(a, n, "Args", n.Args)
+		node = n
+
+	case Cast:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case Clone:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case CompositeLit:
+		n.Elems = applyList(a, n, "Elems", n.Elems)
+		node = n
+
+	case FieldSelect:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case FuncLit:
+		n.Params = applyList(a, n, "Params", n.Params)
+		n.Results = applyList(a, n, "Results", n.Results)
+		n.Body = applyList(a, n, "Body", n.Body)
+		node = n
+
+	case Index:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		n.Index = applyChild(a, n, "Index", n.Index)
+		node = n
+
+	case KeyValue:
+		n.Value = applyChild(a, n, "Value", n.Value)
+		node = n
+
+	case PointerDereference:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	case Slice:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		n.Low = applyOptional(a, n, "Low", n.Low)
+		n.High = applyOptional(a, n, "High", n.High)
+		node = n
+
+	case Unary:
+		n.Expr = applyChild(a, n, "Expr", n.Expr)
+		node = n
+
+	default:
+		panic("unknown node type: " + node.Node())
+	}
+
+	return node
+}
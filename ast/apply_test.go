@@ -0,0 +1,195 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tsavola/dp/ast"
+	"github.com/tsavola/dp/format"
+	"github.com/tsavola/dp/lex"
+	"github.com/tsavola/dp/parse"
+	"github.com/tsavola/dp/source"
+)
+
+func TestApply(t *testing.T) {
+	const input = `f() I64 {
+	x := 1
+	debug(x)
+	return x + 2
+}
+`
+
+	const expect = `f() I64 {
+	x := 1
+	trace()
+	return x + 3
+}
+`
+
+	parsed, err := parse.Stream(lex.NewStringScanner(source.Location("input"), input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isCallTo := func(node ast.Node, name string) bool {
+		if x, ok := node.(ast.Expression); ok {
+			if call, ok := x.Expr.(ast.Call); ok {
+				if sel, ok := call.Func.(ast.Selector); ok {
+					return sel.String() == name
+				}
+			}
+		}
+		return false
+	}
+
+	var names []string
+
+	result := ast.Apply(parsed[0],
+		func(c *ast.Cursor) bool {
+			switch node := c.Node().(type) {
+			case ast.Expression:
+				if isCallTo(node, "debug") {
+					trace := ast.Call{ast.Selector{node.Pos(), []string{"trace"}, node.Pos()}, nil, node.End()}
+					c.InsertAfter(ast.Expression{trace})
+					c.Delete()
+				}
+			case ast.Integer:
+				if node.Source == "2" {
+					c.Replace(ast.Integer{node.At, "3"})
+				}
+			}
+			return true
+		},
+		func(c *ast.Cursor) bool {
+			if c.Name() == "Body" {
+				names = append(names, fmt.Sprintf("%s:%d", c.Node().Node(), c.Index()))
+			}
+			return true
+		},
+	)
+
+	if formatted := string(format.File([]ast.FileChild{result.(ast.FileChild)})); formatted != expect {
+		t.Errorf("formatted:\n%s", formatted)
+	}
+	if formatted := string(format.File(parsed)); formatted != input {
+		t.Errorf("original modified:\n%s", formatted)
+	}
+	if s := strings.Join(names, " "); s != "VariableDef:0 Return:2" {
+		t.Errorf("post-visited: %s", s)
+	}
+
+	result = ast.Apply(parsed[0],
+		func(c *ast.Cursor) bool {
+			if node, ok := c.Node().(ast.Integer); ok {
+				c.Replace(ast.Integer{node.At, node.Source + "0"})
+			}
+			return true
+		},
+		func(c *ast.Cursor) bool {
+			_, ok := c.Node().(ast.Integer)
+			return !ok // Stop after first integer.
+		},
+	)
+
+	if s := result.(ast.FunctionDef).Body[2].Dump(); s != "Return{ Expression{Binary{Selector{x} BinaryOp{+} Integer{2}}}}" {
+		t.Errorf("stopped: %s", s)
+	}
+	if s := result.(ast.FunctionDef).Body[0].Dump(); s != "VariableDef{x := Expression{Integer{10}}}" {
+		t.Errorf("stopped: %s", s)
+	}
+
+	for _, f := range []func(*ast.Cursor){
+		(*ast.Cursor).Delete,
+		func(c *ast.Cursor) { c.InsertBefore(c.Node()) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			ast.Apply(parsed[0], func(c *ast.Cursor) bool {
+				f(c)
+				return true
+			}, nil)
+		}()
+	}
+}
+
+func TestApplyReplace(t *testing.T) {
+	const input = `Color enum {
+	red = 1
+}
+
+f(a &[I64]) I64 {
+	for x < 10 {
+		x += 1
+	}
+	return a[1:2][0]
+}
+`
+
+	const expect = `Color enum {
+	red
+}
+
+f(a &[I64]) I64 {
+	for {
+		x += 1
+	}
+	return a[:2][0]
+}
+`
+
+	parsed, err := parse.Stream(lex.NewStringScanner(source.Location("input"), input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result []ast.FileChild
+	for _, node := range parsed {
+		node = ast.Apply(node, func(c *ast.Cursor) bool {
+			switch c.Name() {
+			case "Value", "Test", "Low":
+				c.Replace(nil)
+			}
+			return true
+		}, nil).(ast.FileChild)
+		result = append(result, node)
+	}
+
+	if formatted := string(format.File(result)); formatted != expect {
+		t.Errorf("formatted:\n%s", formatted)
+	}
+
+	for _, x := range []struct {
+		name  string
+		node  ast.Node
+		panic string
+	}{
+		{"Test", ast.Break{}, "For field Test cannot hold Break"},
+		{"Body", nil, "For field Body cannot hold nil"},
+		{"Left", nil, "Binary field Left cannot hold nil"},
+	} {
+		func() {
+			defer func() {
+				if s := fmt.Sprint(recover()); s != x.panic {
+					t.Errorf("%s: panic: %s", x.name, s)
+				}
+			}()
+			ast.Apply(parsed[1], func(c *ast.Cursor) bool {
+				if c.Name() == x.name {
+					switch c.Parent().(type) {
+					case ast.For, ast.Binary:
+						c.Replace(x.node)
+					}
+				}
+				return true
+			}, nil)
+		}()
+	}
+}
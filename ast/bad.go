@@ -0,0 +1,35 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+import (
+	"github.com/tsavola/dp/internal/position"
+	"github.com/tsavola/dp/source"
+)
+
+// BadDecl is a placeholder for a top-level declaration which couldn't be
+// parsed.  Source is the original text.
+type BadDecl struct {
+	At     source.Position
+	Source string
+}
+
+func (BadDecl) Node() string           { return "BadDecl" }
+func (BadDecl) fileChild()             {}
+func (x BadDecl) Pos() source.Position { return x.At }
+func (x BadDecl) End() source.Position { return position.After(x.At, x.Source) }
+func (x BadDecl) Dump() string         { return "BadDecl{" + x.Source + "}" }
+
+// BadStmt is a placeholder for a statement which couldn't be parsed.  Source
+// is the original text.
+type BadStmt struct {
+	At     source.Position
+	Source string
+}
+
+func (BadStmt) Node() string           { return "BadStmt" }
+func (BadStmt) blockChild()            {}
+func (x BadStmt) Pos() source.Position { return x.At }
+func (x BadStmt) End() source.Position { return position.After(x.At, x.Source) }
+func (x BadStmt) Dump() string         { return "BadStmt{" + x.Source + "}" }
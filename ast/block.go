@@ -15,6 +15,7 @@ type Expression struct {
 
 func (Expression) Node() string           { return "Expression" }
 func (Expression) blockChild()            {}
+func (Expression) elemListChild()         {}
 func (Expression) exprListChild()         {}
 func (x Expression) Pos() source.Position { return x.Expr.Pos() }
 func (x Expression) End() source.Position { return x.Expr.End() }
@@ -23,6 +24,7 @@ func (x Expression) Dump() string         { return "Expression{" + x.Expr.Dump()
 type Assign struct {
 	At      source.Position
 	Targets []AssignListChild
+	Op      BinaryOp // Zero if plain assignment.
 	Values  []ExprListChild
 	EndAt   source.Position
 }
@@ -42,6 +44,9 @@ func (x Assign) Dump() string {
 	}
 
 	delim = " = "
+	if x.Op != 0 {
+		delim = " " + x.Op.String() + "= "
+	}
 	for _, node := range x.Values {
 		if !IsComment(node) {
 			s += delim + node.Dump()
@@ -82,6 +87,7 @@ func (x Block) Dump() string {
 
 type Break struct {
 	At    source.Position
+	Label string // Empty if unlabeled.
 	EndAt source.Position
 }
 
@@ -89,10 +95,17 @@ func (Break) Node() string           { return "Break" }
 func (Break) blockChild()            {}
 func (x Break) Pos() source.Position { return x.At }
 func (x Break) End() source.Position { return x.EndAt }
-func (Break) Dump() string           { return "Break" }
+
+func (x Break) Dump() string {
+	if x.Label == "" {
+		return "Break"
+	}
+	return "Break{" + x.Label + "}"
+}
 
 type Continue struct {
 	At    source.Position
+	Label string // Empty if unlabeled.
 	EndAt source.Position
 }
 
@@ -100,11 +113,33 @@ func (Continue) Node() string           { return "Continue" }
 func (Continue) blockChild()            {}
 func (x Continue) Pos() source.Position { return x.At }
 func (x Continue) End() source.Position { return x.EndAt }
-func (Continue) Dump() string           { return "Continue" }
+
+func (x Continue) Dump() string {
+	if x.Label == "" {
+		return "Continue"
+	}
+	return "Continue{" + x.Label + "}"
+}
+
+type Defer struct {
+	At    source.Position
+	Expr  ExprChild
+	EndAt source.Position
+}
+
+func (Defer) Node() string           { return "Defer" }
+func (Defer) blockChild()            {}
+func (x Defer) Pos() source.Position { return x.At }
+func (x Defer) End() source.Position { return x.EndAt }
+func (x Defer) Dump() string         { return "Defer{" + x.Expr.Dump() + "}" }
 
 type For struct {
-	At     source.Position
-	Test   ExprChild // Nil if infinite loop.
+	At     source.Position // Position of label if labeled.
+	Label  string          // Empty if unlabeled.
+	Init   BlockChild      // Assign, Expression or VariableDef.  Nil if none.
+	Test   ExprChild       // Nil if infinite or range loop.
+	Post   BlockChild      // Assign or Expression.  Nil if none.
+	Range  *ForRange       // Nil unless range loop.
 	BodyAt source.Position
 	Body   []BlockChild
 	EndAt  source.Position
@@ -115,20 +150,62 @@ func (For) blockChild()            {}
 func (x For) Pos() source.Position { return x.At }
 func (x For) End() source.Position { return x.EndAt }
 
+// Clauses reports if the loop has initialization or post statement.
+func (x For) Clauses() bool {
+	return x.Init != nil || x.Post != nil
+}
+
 func (x For) Dump() string {
 	s := "For{"
-	if x.Test != nil {
+	if x.Label != "" {
+		s += x.Label + ": "
+	}
+	switch {
+	case x.Range != nil:
+		s += x.Range.Dump() + " "
+
+	case x.Clauses():
+		if x.Init != nil {
+			s += x.Init.Dump()
+		}
+		s += "; "
+		if x.Test != nil {
+			s += x.Test.Dump()
+		}
+		s += "; "
+		if x.Post != nil {
+			s += x.Post.Dump() + " "
+		}
+
+	case x.Test != nil:
 		s += x.Test.Dump() + " "
 	}
 	return s + Block{x.At, x.Body, x.EndAt}.Dump() + "}"
 }
 
+// ForRange is the header of a loop over array items.
+type ForRange struct {
+	At    source.Position
+	Names []string // Index variable and optional item variable.
+	Expr  ExprChild
+	EndAt source.Position
+}
+
+func (ForRange) Node() string           { return "ForRange" }
+func (x ForRange) Pos() source.Position { return x.At }
+func (x ForRange) End() source.Position { return x.EndAt }
+
+func (x ForRange) Dump() string {
+	return "ForRange{" + dumpNameList(x.Names) + " := range " + x.Expr.Dump() + "}"
+}
+
 type If struct {
 	At        source.Position
 	Test      ExprChild
 	ThenAt    source.Position
 	Then      []BlockChild
 	ThenEndAt source.Position
+	ElseIf    bool // Else consists of a single chained If.
 	Else      []BlockChild
 	EndAt     source.Position
 }
@@ -140,7 +217,9 @@ func (x If) End() source.Position { return x.EndAt }
 
 func (x If) Dump() string {
 	s := "If{" + x.Test.Dump() + " " + Block{x.At, x.Then, x.ThenEndAt}.Dump()
-	if len(x.Else) > 0 {
+	if x.ElseIf {
+		s += " else " + x.Else[0].Dump()
+	} else if len(x.Else) > 0 {
 		s += " else " + Block{x.ThenEndAt, x.Else, x.EndAt}.Dump()
 	}
 	return s + "}"
@@ -178,6 +257,59 @@ func (x Return) Dump() string {
 	return s + "}"
 }
 
+type Switch struct {
+	At     source.Position
+	Value  ExprChild
+	BodyAt source.Position
+	Cases  []CaseListChild
+	EndAt  source.Position
+}
+
+func (Switch) Node() string           { return "Switch" }
+func (Switch) blockChild()            {}
+func (x Switch) Pos() source.Position { return x.At }
+func (x Switch) End() source.Position { return x.EndAt }
+
+func (x Switch) Dump() string {
+	s := "Switch{" + x.Value.Dump()
+	for _, node := range x.Cases {
+		if !IsComment(node) {
+			s += " " + node.Dump()
+		}
+	}
+	return s + "}"
+}
+
+type Case struct {
+	At      source.Position
+	Default bool
+	Values  []ExprListChild // Empty if default.
+	Body    []BlockChild
+	EndAt   source.Position
+}
+
+func (Case) Node() string           { return "Case" }
+func (Case) caseListChild()         {}
+func (x Case) Pos() source.Position { return x.At }
+func (x Case) End() source.Position { return x.EndAt }
+
+func (x Case) Dump() string {
+	s := "Case{"
+	if x.Default {
+		s += "default"
+	}
+
+	delim := ""
+	for _, node := range x.Values {
+		if !IsComment(node) {
+			s += delim + node.Dump()
+			delim = ", "
+		}
+	}
+
+	return s + ": " + Block{x.At, x.Body, x.EndAt}.Dump() + "}"
+}
+
 type VariableDecl struct {
 	At    source.Position
 	Names []string
@@ -6,6 +6,7 @@ package ast
 import (
 	"strings"
 
+	"github.com/tsavola/dp/internal/literal"
 	"github.com/tsavola/dp/internal/position"
 	"github.com/tsavola/dp/source"
 )
@@ -63,7 +64,7 @@ func (x Boolean) End() source.Position { return position.After(x.At, x.Source) }
 func (x Boolean) Dump() string         { return "Boolean{" + x.Source + "}" }
 
 type Call struct {
-	Name  Selector
+	Func  ExprChild
 	Args  []ExprListChild
 	EndAt source.Position
 }
@@ -71,11 +72,11 @@ type Call struct {
 func (Call) Node() string           { return "Call" }
 func (Call) assignListChild()       {}
 func (Call) exprChild()             {}
-func (x Call) Pos() source.Position { return x.Name.Pos() }
+func (x Call) Pos() source.Position { return x.Func.Pos() }
 func (x Call) End() source.Position { return x.EndAt }
 
 func (x Call) Dump() string {
-	s := "Call{" + x.Name.Dump() + " ("
+	s := "Call{" + x.Func.Dump() + " ("
 
 	delim := ""
 	for _, node := range x.Args {
@@ -119,6 +120,11 @@ func (x Character) Pos() source.Position { return x.At }
 func (x Character) End() source.Position { return position.After(x.At, x.Source) }
 func (x Character) Dump() string         { return "Character{" + x.Source + "}" }
 
+// Value decodes the source.
+func (x Character) Value() (rune, error) {
+	return literal.UnquoteChar(x.Source)
+}
+
 type Clone struct {
 	At    source.Position
 	Expr  ExprChild
@@ -131,6 +137,38 @@ func (x Clone) Pos() source.Position { return x.At }
 func (x Clone) End() source.Position { return x.EndAt }
 func (x Clone) Dump() string         { return "Clone{" + x.Expr.Dump() + "}" }
 
+type CompositeLit struct {
+	At    source.Position
+	Type  Type
+	Elems []ElemListChild
+	EndAt source.Position
+}
+
+func (CompositeLit) Node() string           { return "CompositeLit" }
+func (CompositeLit) exprChild()             {}
+func (x CompositeLit) Pos() source.Position { return x.At }
+func (x CompositeLit) End() source.Position { return x.EndAt }
+
+func (x CompositeLit) Dump() string {
+	s := "CompositeLit{" + x.Type.String() + " {"
+
+	delim := ""
+	for _, node := range x.Elems {
+		VisitElemListChild(node,
+			func(Comment) {},
+			func(node Expression) {
+				s += delim + node.Dump()
+				delim = ", "
+			},
+			func(node KeyValue) {
+				s += delim + node.Dump()
+				delim = ", "
+			},
+		)
+	}
+	return s + "}}"
+}
+
 type Empty struct {
 	At    source.Position
 	EndAt source.Position
@@ -142,8 +180,52 @@ func (x Empty) Pos() source.Position { return x.At }
 func (x Empty) End() source.Position { return x.EndAt }
 func (Empty) Dump() string           { return "Empty" }
 
+// FieldSelect is a field of an expression which is not a plain Selector.
+type FieldSelect struct {
+	Expr  ExprChild
+	Name  string
+	EndAt source.Position
+}
+
+func (FieldSelect) Node() string           { return "FieldSelect" }
+func (FieldSelect) assignListChild()       {}
+func (FieldSelect) exprChild()             {}
+func (x FieldSelect) Pos() source.Position { return x.Expr.Pos() }
+func (x FieldSelect) End() source.Position { return x.EndAt }
+func (x FieldSelect) Dump() string         { return "FieldSelect{" + x.Expr.Dump() + " ." + x.Name + "}" }
+
+type Float struct {
+	At     source.Position
+	Source string
+}
+
+func (Float) Node() string           { return "Float" }
+func (Float) exprChild()             {}
+func (x Float) Pos() source.Position { return x.At }
+func (x Float) End() source.Position { return position.After(x.At, x.Source) }
+func (x Float) Dump() string         { return "Float{" + x.Source + "}" }
+
+type FuncLit struct {
+	At          source.Position
+	Params      []ParamListChild
+	ParamsEndAt source.Position
+	Results     []TypeListChild
+	BodyAt      source.Position
+	Body        []BlockChild
+	EndAt       source.Position
+}
+
+func (FuncLit) Node() string           { return "FuncLit" }
+func (FuncLit) exprChild()             {}
+func (x FuncLit) Pos() source.Position { return x.At }
+func (x FuncLit) End() source.Position { return x.EndAt }
+
+func (x FuncLit) Dump() string {
+	return "FuncLit{func" + dumpSignature(x.Params, x.Results) + Block{x.BodyAt, x.Body, x.EndAt}.Dump() + "}"
+}
+
 type Index struct {
-	Name  Selector
+	Expr  ExprChild
 	Index ExprChild
 	EndAt source.Position
 }
@@ -151,9 +233,9 @@ type Index struct {
 func (Index) Node() string           { return "Index" }
 func (Index) assignListChild()       {}
 func (Index) exprChild()             {}
-func (x Index) Pos() source.Position { return x.Name.Pos() }
+func (x Index) Pos() source.Position { return x.Expr.Pos() }
 func (x Index) End() source.Position { return x.EndAt }
-func (x Index) Dump() string         { return "Index{" + x.Name.Dump() + " [" + x.Index.Dump() + "]}" }
+func (x Index) Dump() string         { return "Index{" + x.Expr.Dump() + " [" + x.Index.Dump() + "]}" }
 
 type Integer struct {
 	At     source.Position
@@ -166,6 +248,19 @@ func (x Integer) Pos() source.Position { return x.At }
 func (x Integer) End() source.Position { return position.After(x.At, x.Source) }
 func (x Integer) Dump() string         { return "Integer{" + x.Source + "}" }
 
+// KeyValue is a keyed element of a composite literal.
+type KeyValue struct {
+	At    source.Position
+	Key   string
+	Value ExprChild
+}
+
+func (KeyValue) Node() string           { return "KeyValue" }
+func (KeyValue) elemListChild()         {}
+func (x KeyValue) Pos() source.Position { return x.At }
+func (x KeyValue) End() source.Position { return x.Value.End() }
+func (x KeyValue) Dump() string         { return "KeyValue{" + x.Key + ": " + x.Value.Dump() + "}" }
+
 type Nil struct {
 	At    source.Position
 	EndAt source.Position
@@ -203,6 +298,31 @@ func (x Selector) End() source.Position { return x.EndAt }
 func (x Selector) Dump() string         { return "Selector{" + x.String() + "}" }
 func (x Selector) String() string       { return strings.Join(x.Name, ".") }
 
+// Slice bounds are nil if omitted.
+type Slice struct {
+	Expr  ExprChild
+	Low   ExprChild
+	High  ExprChild
+	EndAt source.Position
+}
+
+func (Slice) Node() string           { return "Slice" }
+func (Slice) exprChild()             {}
+func (x Slice) Pos() source.Position { return x.Expr.Pos() }
+func (x Slice) End() source.Position { return x.EndAt }
+
+func (x Slice) Dump() string {
+	s := "Slice{" + x.Expr.Dump() + " ["
+	if x.Low != nil {
+		s += x.Low.Dump()
+	}
+	s += ":"
+	if x.High != nil {
+		s += x.High.Dump()
+	}
+	return s + "]}"
+}
+
 type String struct {
 	At     source.Position
 	Source string
@@ -214,6 +334,11 @@ func (x String) Pos() source.Position { return x.At }
 func (x String) End() source.Position { return position.After(x.At, x.Source) }
 func (x String) Dump() string         { return "String{" + x.Source + "}" }
 
+// Value decodes the source.
+func (x String) Value() (string, error) {
+	return literal.Unquote(x.Source)
+}
+
 type Unary struct {
 	At    source.Position
 	Op    UnaryOp
@@ -18,17 +18,29 @@ type Comment struct {
 
 func (Comment) Node() string           { return "Comment" }
 func (Comment) blockChild()            {}
+func (Comment) caseListChild()         {}
+func (Comment) elemListChild()         {}
+func (Comment) enumListChild()         {}
 func (Comment) exprListChild()         {}
 func (Comment) fieldListChild()        {}
 func (Comment) fileChild()             {}
 func (Comment) identListChild()        {}
 func (Comment) importListChild()       {}
+func (Comment) methodListChild()       {}
 func (Comment) paramListChild()        {}
 func (Comment) typeListChild()         {}
 func (x Comment) Pos() source.Position { return x.At }
 func (x Comment) End() source.Position { return position.After(x.At, x.Source) }
 func (x Comment) Dump() string         { return "Comment{" + x.Source + "}" }
 
+// IsBlock reports if the comment is delimited by /* and */.
+func (x Comment) IsBlock() bool { return strings.HasPrefix(x.Source, "/*") }
+
+// IsDoc reports if the comment is a /// documentation comment.
+func (x Comment) IsDoc() bool {
+	return strings.HasPrefix(x.Source, "///") && !strings.HasPrefix(x.Source, "////")
+}
+
 func IsComment(node Node) bool {
 	_, ok := node.(Comment)
 	return ok
@@ -57,12 +69,72 @@ func (x ConstantDef) Dump() string {
 	return "ConstantDef{" + s + "}"
 }
 
+type EnumDef struct {
+	At       source.Position
+	Public   bool
+	TypeName string
+	Members  []EnumListChild
+	EndAt    source.Position
+}
+
+func (EnumDef) Node() string           { return "EnumDef" }
+func (EnumDef) fileChild()             {}
+func (x EnumDef) Pos() source.Position { return x.At }
+func (x EnumDef) End() source.Position { return x.EndAt }
+func (x EnumDef) Name() string         { return x.TypeName }
+func (x EnumDef) IsPublic() bool       { return x.Public }
+
+func (x EnumDef) Dump() string {
+	s := x.TypeName + " enum {"
+	if x.Public {
+		s = "pub " + s
+	}
+
+	delim := false
+	for _, node := range x.Members {
+		VisitEnumListChild(node,
+			func(Comment) {},
+			func(node EnumMember) {
+				if delim {
+					s += "; "
+				}
+				s += node.Dump()
+				delim = true
+			},
+		)
+	}
+
+	return "EnumDef{" + s + "}}"
+}
+
+type EnumMember struct {
+	At         source.Position
+	MemberName string
+	Value      ExprChild // Nil if previous value is incremented.
+	EndAt      source.Position
+}
+
+func (EnumMember) Node() string           { return "EnumMember" }
+func (EnumMember) enumListChild()         {}
+func (x EnumMember) Pos() source.Position { return x.At }
+func (x EnumMember) End() source.Position { return x.EndAt }
+func (x EnumMember) Name() string         { return x.MemberName }
+
+func (x EnumMember) Dump() string {
+	s := x.MemberName
+	if x.Value != nil {
+		s += " = " + x.Value.Dump()
+	}
+	return "EnumMember{" + s + "}"
+}
+
 type FunctionDef struct {
 	At           source.Position
 	Public       bool
 	ReceiverName string
 	ReceiverType *TypeSpec
 	FuncName     string
+	TypeParams   []string
 	Params       []ParamListChild
 	ParamsEndAt  source.Position
 	Results      []TypeListChild
@@ -79,13 +151,26 @@ func (x FunctionDef) Name() string         { return x.FuncName }
 func (x FunctionDef) IsPublic() bool       { return x.Public }
 
 func (x FunctionDef) Dump() string {
-	s := x.FuncName + "("
+	s := x.FuncName + dumpTypeParams(x.TypeParams) + dumpSignature(x.Params, x.Results)
 	if x.Public {
 		s = "pub " + s
 	}
 
+	return "FunctionDef{" + s + Block{x.At, x.Body, x.EndAt}.Dump() + "}"
+}
+
+func dumpTypeParams(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func dumpSignature(params []ParamListChild, results []TypeListChild) string {
+	s := "("
+
 	delim := false
-	for _, node := range x.Params {
+	for _, node := range params {
 		VisitParamListChild(node,
 			func(Comment) {},
 			func(node Parameter) {
@@ -98,15 +183,15 @@ func (x FunctionDef) Dump() string {
 		)
 	}
 
-	switch len(x.Results) {
+	switch len(results) {
 	case 0:
 		s += ") "
 	case 1:
-		s += ") " + x.Results[0].Dump() + " "
+		s += ") " + results[0].Dump() + " "
 	default:
 		s += ") ("
 		delim := false
-		for _, node := range x.Results {
+		for _, node := range results {
 			VisitTypeListChild(node,
 				func(Comment) {},
 				func(node TypeSpec) {
@@ -121,7 +206,7 @@ func (x FunctionDef) Dump() string {
 		s += ") "
 	}
 
-	return "FunctionDef{" + s + Block{x.At, x.Body, x.EndAt}.Dump() + "}"
+	return s
 }
 
 type Field struct {
@@ -228,12 +313,75 @@ func (x Parameter) Dump() string         { return "Parameter{" + strings.Join(x.
 func (x Parameter) dumpRow() []string    { return []string{x.ParamName, x.Type.Dump()} }
 func (x Parameter) Name() string         { return x.ParamName }
 
+type InterfaceDef struct {
+	At         source.Position
+	Public     bool
+	TypeName   string
+	TypeParams []string
+	Methods    []MethodListChild
+	EndAt      source.Position
+}
+
+func (InterfaceDef) Node() string           { return "InterfaceDef" }
+func (InterfaceDef) fileChild()             {}
+func (x InterfaceDef) Pos() source.Position { return x.At }
+func (x InterfaceDef) End() source.Position { return x.EndAt }
+func (x InterfaceDef) Name() string         { return x.TypeName }
+func (x InterfaceDef) IsPublic() bool       { return x.Public }
+
+func (x InterfaceDef) Dump() string {
+	s := x.TypeName + dumpTypeParams(x.TypeParams) + " interface {"
+	if x.Public {
+		s = "pub " + s
+	}
+
+	delim := false
+	for _, node := range x.Methods {
+		VisitMethodListChild(node,
+			func(Comment) {},
+			func(node MethodSig) {
+				if delim {
+					s += "; "
+				}
+				s += node.Dump()
+				delim = true
+			},
+		)
+	}
+
+	return "InterfaceDef{" + s + "}}"
+}
+
+// MethodSig is a method signature in an interface definition.  Receiver type
+// has only the modifiers.
+type MethodSig struct {
+	At          source.Position
+	Receiver    Type
+	MethodName  string
+	Params      []ParamListChild
+	ParamsEndAt source.Position
+	Results     []TypeListChild
+	EndAt       source.Position
+}
+
+func (MethodSig) Node() string           { return "MethodSig" }
+func (MethodSig) methodListChild()       {}
+func (x MethodSig) Pos() source.Position { return x.At }
+func (x MethodSig) End() source.Position { return x.EndAt }
+func (x MethodSig) Name() string         { return x.MethodName }
+
+func (x MethodSig) Dump() string {
+	s := "(" + x.Receiver.String() + ") " + x.MethodName + dumpSignature(x.Params, x.Results)
+	return "MethodSig{" + strings.TrimSuffix(s, " ") + "}"
+}
+
 type TypeDef struct {
-	At       source.Position
-	Public   bool
-	TypeName string
-	Fields   []FieldListChild
-	EndAt    source.Position
+	At         source.Position
+	Public     bool
+	TypeName   string
+	TypeParams []string
+	Fields     []FieldListChild
+	EndAt      source.Position
 }
 
 func (TypeDef) Node() string           { return "TypeDef" }
@@ -244,7 +392,7 @@ func (x TypeDef) Name() string         { return x.TypeName }
 func (x TypeDef) IsPublic() bool       { return x.Public }
 
 func (x TypeDef) Dump() string {
-	s := x.TypeName + " {"
+	s := x.TypeName + dumpTypeParams(x.TypeParams) + " {"
 	if x.Public {
 		s = "pub " + s
 	}
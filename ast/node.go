@@ -25,6 +25,21 @@ type BlockChild interface {
 	blockChild()
 }
 
+type CaseListChild interface {
+	Node
+	caseListChild()
+}
+
+type ElemListChild interface {
+	Node
+	elemListChild()
+}
+
+type EnumListChild interface {
+	Node
+	enumListChild()
+}
+
 type ExprChild interface {
 	Node
 	exprChild()
@@ -55,6 +70,11 @@ type ImportListChild interface {
 	importListChild()
 }
 
+type MethodListChild interface {
+	Node
+	methodListChild()
+}
+
 type ParamListChild interface {
 	Node
 	paramListChild()
@@ -15,7 +15,9 @@ type Type struct {
 	Reference bool
 	Shared    bool
 	Item      *Type         // Non-nil if array.
-	Name      QualifiedName // Empty if array.
+	Func      *FuncType     // Non-nil if function.
+	Name      QualifiedName // Empty if array or function.
+	Args      []Type        // Type arguments of named generic type.
 }
 
 func (t Type) Equal(other Type) bool {
@@ -28,15 +30,30 @@ func (t Type) Equal(other Type) bool {
 			return false
 		}
 	}
-	return slices.Equal(t.Name, other.Name) && t.Shared == other.Shared && t.Reference == other.Reference && t.Pointer == other.Pointer && t.Assigner == other.Assigner
+	if t.Func != nil {
+		if other.Func == nil || !t.Func.Equal(*other.Func) {
+			return false
+		}
+	} else {
+		if other.Func != nil {
+			return false
+		}
+	}
+	return slices.Equal(t.Name, other.Name) && slices.EqualFunc(t.Args, other.Args, Type.Equal) && t.Shared == other.Shared && t.Reference == other.Reference && t.Pointer == other.Pointer && t.Assigner == other.Assigner
 }
 
 func (t Type) String() string {
 	var s string
-	if t.Item != nil {
+	switch {
+	case t.Item != nil:
 		s = "[" + t.Item.String() + "]"
-	} else {
+	case t.Func != nil:
+		s = t.Func.String()
+	default:
 		s = t.Name.String()
+		if len(t.Args) > 0 {
+			s += "[" + joinTypes(t.Args) + "]"
+		}
 	}
 	if t.Shared {
 		s = "#" + s
@@ -53,6 +70,39 @@ func (t Type) String() string {
 	return s
 }
 
+// FuncType lists parameter and result types of a function.
+type FuncType struct {
+	Params  []Type
+	Results []Type
+}
+
+func (t FuncType) Equal(other FuncType) bool {
+	return slices.EqualFunc(t.Params, other.Params, Type.Equal) && slices.EqualFunc(t.Results, other.Results, Type.Equal)
+}
+
+func (t FuncType) String() string {
+	s := "func(" + joinTypes(t.Params) + ")"
+	switch len(t.Results) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + t.Results[0].String()
+	default:
+		return s + " (" + joinTypes(t.Results) + ")"
+	}
+}
+
+func joinTypes(types []Type) string {
+	var s string
+	for i, t := range types {
+		if i > 0 {
+			s += ", "
+		}
+		s += t.String()
+	}
+	return s
+}
+
 type TypeSpec struct {
 	At source.Position
 	Type
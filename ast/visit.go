@@ -7,6 +7,7 @@ func VisitAssignListChild(x AssignListChild,
 	visitAssigner func(AssignerDereference),
 	visitCall func(Call),
 	visitCast func(Cast),
+	visitFieldSelect func(FieldSelect),
 	visitIndex func(Index),
 	visitSelector func(Selector),
 ) {
@@ -17,6 +18,8 @@ func VisitAssignListChild(x AssignListChild,
 		visitCall(x)
 	case Cast:
 		visitCast(x)
+	case FieldSelect:
+		visitFieldSelect(x)
 	case Index:
 		visitIndex(x)
 	case Selector:
@@ -28,21 +31,26 @@ func VisitAssignListChild(x AssignListChild,
 
 func VisitBlockChild(x BlockChild,
 	visitAssign func(Assign),
+	visitBadStmt func(BadStmt),
 	visitBlock func(Block),
 	visitBreak func(Break),
 	visitComment func(Comment),
 	visitContinue func(Continue),
+	visitDefer func(Defer),
 	visitExpression func(Expression),
 	visitFor func(For),
 	visitIf func(If),
 	visitImport func(Import),
 	visitReturn func(Return),
+	visitSwitch func(Switch),
 	visitVariableDecl func(VariableDecl),
 	visitVariableDef func(VariableDef),
 ) {
 	switch x := x.(type) {
 	case Assign:
 		visitAssign(x)
+	case BadStmt:
+		visitBadStmt(x)
 	case Block:
 		visitBlock(x)
 	case Break:
@@ -51,6 +59,8 @@ func VisitBlockChild(x BlockChild,
 		visitComment(x)
 	case Continue:
 		visitContinue(x)
+	case Defer:
+		visitDefer(x)
 	case Expression:
 		visitExpression(x)
 	case For:
@@ -61,6 +71,8 @@ func VisitBlockChild(x BlockChild,
 		visitImport(x)
 	case Return:
 		visitReturn(x)
+	case Switch:
+		visitSwitch(x)
 	case VariableDecl:
 		visitVariableDecl(x)
 	case VariableDef:
@@ -70,6 +82,51 @@ func VisitBlockChild(x BlockChild,
 	}
 }
 
+func VisitCaseListChild(x CaseListChild,
+	visitCase func(Case),
+	visitComment func(Comment),
+) {
+	switch x := x.(type) {
+	case Case:
+		visitCase(x)
+	case Comment:
+		visitComment(x)
+	default:
+		panic("unknown case list child node type")
+	}
+}
+
+func VisitElemListChild(x ElemListChild,
+	visitComment func(Comment),
+	visitExpression func(Expression),
+	visitKeyValue func(KeyValue),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Expression:
+		visitExpression(x)
+	case KeyValue:
+		visitKeyValue(x)
+	default:
+		panic("unknown element list child node type")
+	}
+}
+
+func VisitEnumListChild(x EnumListChild,
+	visitComment func(Comment),
+	visitMember func(EnumMember),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case EnumMember:
+		visitMember(x)
+	default:
+		panic("unknown enum list child node type")
+	}
+}
+
 func VisitExpr(x ExprChild,
 	visitAddress func(Address),
 	visitBinary func(Binary),
@@ -78,12 +135,17 @@ func VisitExpr(x ExprChild,
 	visitCast func(Cast),
 	visitCharacter func(Character),
 	visitClone func(Clone),
+	visitComposite func(CompositeLit),
 	visitEmpty func(Empty),
+	visitFieldSelect func(FieldSelect),
+	visitFloat func(Float),
+	visitFuncLit func(FuncLit),
 	visitIndex func(Index),
 	visitInteger func(Integer),
 	visitNil func(Nil),
 	visitPointer func(PointerDereference),
 	visitSelector func(Selector),
+	visitSlice func(Slice),
 	visitString func(String),
 	visitUnary func(Unary),
 ) {
@@ -102,8 +164,16 @@ func VisitExpr(x ExprChild,
 		visitCharacter(x)
 	case Clone:
 		visitClone(x)
+	case CompositeLit:
+		visitComposite(x)
 	case Empty:
 		visitEmpty(x)
+	case FieldSelect:
+		visitFieldSelect(x)
+	case Float:
+		visitFloat(x)
+	case FuncLit:
+		visitFuncLit(x)
 	case Index:
 		visitIndex(x)
 	case Integer:
@@ -114,6 +184,8 @@ func VisitExpr(x ExprChild,
 		visitPointer(x)
 	case Selector:
 		visitSelector(x)
+	case Slice:
+		visitSlice(x)
 	case String:
 		visitString(x)
 	case Unary:
@@ -158,24 +230,33 @@ func VisitFieldListChild(x FieldListChild,
 }
 
 func VisitFileChild(x FileChild,
+	visitBadDecl func(BadDecl),
 	visitComment func(Comment),
 	visitConstant func(ConstantDef),
+	visitEnum func(EnumDef),
 	visitFunction func(FunctionDef),
 	visitImport func(Import),
 	visitImports func(Imports),
+	visitInterface func(InterfaceDef),
 	visitType func(TypeDef),
 ) {
 	switch x := x.(type) {
+	case BadDecl:
+		visitBadDecl(x)
 	case Comment:
 		visitComment(x)
 	case ConstantDef:
 		visitConstant(x)
+	case EnumDef:
+		visitEnum(x)
 	case FunctionDef:
 		visitFunction(x)
 	case Import:
 		visitImport(x)
 	case Imports:
 		visitImports(x)
+	case InterfaceDef:
+		visitInterface(x)
 	case TypeDef:
 		visitType(x)
 	default:
@@ -211,6 +292,20 @@ func VisitImportListChild(x ImportListChild,
 	}
 }
 
+func VisitMethodListChild(x MethodListChild,
+	visitComment func(Comment),
+	visitMethod func(MethodSig),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case MethodSig:
+		visitMethod(x)
+	default:
+		panic("unknown method list child node type")
+	}
+}
+
 func VisitParamListChild(x ParamListChild,
 	visitComment func(Comment),
 	visitParameter func(Parameter),
@@ -0,0 +1,212 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+// Visitor's Visit method is invoked for each node encountered by Walk.  If the
+// result visitor w is not nil, Walk visits each of the children of node with
+// the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, visiting children in source
+// order.  It starts by calling v.Visit(node); node must not be nil.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	// File
+
+	case BadDecl, Comment:
+
+	case ConstantDef:
+		Walk(v, n.Value)
+
+	case EnumDef:
+		walkList(v, n.Members)
+
+	case EnumMember:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case Field:
+		Walk(v, n.Type)
+
+	case FunctionDef:
+		if n.ReceiverType != nil {
+			Walk(v, *n.ReceiverType)
+		}
+		walkList(v, n.Params)
+		walkList(v, n.Results)
+		walkList(v, n.Body)
+
+	case Identifier:
+
+	case Import:
+		walkList(v, n.Names)
+
+	case Imports:
+		walkList(v, n.Imports)
+
+	case InterfaceDef:
+		walkList(v, n.Methods)
+
+	case MethodSig:
+		walkList(v, n.Params)
+		walkList(v, n.Results)
+
+	case Parameter:
+		Walk(v, n.Type)
+
+	case TypeDef:
+		walkList(v, n.Fields)
+
+	case TypeSpec:
+
+	// Statements
+
+	case Assign:
+		walkList(v, n.Targets)
+		walkList(v, n.Values)
+
+	case BadStmt, Break, Continue:
+
+	case Block:
+		walkList(v, n.Body)
+
+	case Case:
+		walkList(v, n.Values)
+		walkList(v, n.Body)
+
+	case Defer:
+		Walk(v, n.Expr)
+
+	case Expression:
+		Walk(v, n.Expr)
+
+	case For:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Test != nil {
+			Walk(v, n.Test)
+		}
+		if n.Post != nil {
+			Walk(v, n.Post)
+		}
+		if n.Range != nil {
+			Walk(v, *n.Range)
+		}
+		walkList(v, n.Body)
+
+	case ForRange:
+		Walk(v, n.Expr)
+
+	case If:
+		Walk(v, n.Test)
+		walkList(v, n.Then)
+		walkList(v, n.Else)
+
+	case Return:
+		walkList(v, n.Values)
+
+	case Switch:
+		Walk(v, n.Value)
+		walkList(v, n.Cases)
+
+	case VariableDecl:
+		if n.Type != nil {
+			Walk(v, *n.Type)
+		}
+
+	case VariableDef:
+		walkList(v, n.Values)
+
+	// Expressions
+
+	case Address:
+		Walk(v, n.Expr)
+
+	case AssignerDereference:
+
+	case Binary:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+
+	case Boolean, Character, Empty, Float, Integer, Nil, Selector, String:
+
+	case Call:
+		Walk(v, n.Func)
+		walkList(v, n.Args)
+
+	case Cast:
+		Walk(v, n.Expr)
+
+	case Clone:
+		Walk(v, n.Expr)
+
+	case CompositeLit:
+		walkList(v, n.Elems)
+
+	case FieldSelect:
+		Walk(v, n.Expr)
+
+	case FuncLit:
+		walkList(v, n.Params)
+		walkList(v, n.Results)
+		walkList(v, n.Body)
+
+	case Index:
+		Walk(v, n.Expr)
+		Walk(v, n.Index)
+
+	case KeyValue:
+		Walk(v, n.Value)
+
+	case PointerDereference:
+		Walk(v, n.Expr)
+
+	case Slice:
+		Walk(v, n.Expr)
+		if n.Low != nil {
+			Walk(v, n.Low)
+		}
+		if n.High != nil {
+			Walk(v, n.High)
+		}
+
+	case Unary:
+		Walk(v, n.Expr)
+
+	default:
+		panic("unknown node type: " + node.Node())
+	}
+
+	v.Visit(nil)
+}
+
+func walkList[T Node](v Visitor, nodes []T) {
+	for _, node := range nodes {
+		Walk(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order like Walk.  It starts by
+// calling f(node); node must not be nil.  If f returns true, Inspect invokes f
+// recursively for each of the children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+import (
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestTraversalCoverage checks that Walk and Apply have a case for every node
+// type, and that each case refers to every field which may hold child nodes.
+func TestTraversalCoverage(t *testing.T) {
+	fset := token.NewFileSet()
+
+	var files []*goast.File
+	traversals := map[string]*goast.FuncDecl{
+		"Walk":          nil,
+		"applyChildren": nil,
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, e.Name(), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*goast.FuncDecl); ok {
+				if _, found := traversals[fn.Name.Name]; found {
+					traversals[fn.Name.Name] = fn
+				}
+			}
+		}
+	}
+
+	// Node types and their child fields.
+	nodes := make(map[string][]string)
+	structs := make(map[string]*goast.StructType)
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *goast.FuncDecl:
+				if decl.Recv != nil && decl.Name.Name == "Node" && isNodeMethod(decl.Type) {
+					nodes[receiverTypeName(decl.Recv.List[0].Type)] = nil
+				}
+
+			case *goast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*goast.TypeSpec); ok {
+						if st, ok := spec.Type.(*goast.StructType); ok {
+							structs[spec.Name.Name] = st
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for name := range nodes {
+		st := structs[name]
+		if st == nil {
+			t.Errorf("node type %s is not a struct", name)
+			continue
+		}
+		for _, field := range st.Fields.List {
+			if !isChildFieldType(field.Type, nodes) {
+				continue
+			}
+			for _, ident := range field.Names {
+				nodes[name] = append(nodes[name], ident.Name)
+			}
+		}
+	}
+
+	for name, fn := range traversals {
+		if fn == nil {
+			t.Errorf("%s function not found", name)
+			continue
+		}
+		checkTraversal(t, fn, nodes)
+	}
+}
+
+// checkTraversal checks the node type switch of a traversal function.
+func checkTraversal(t *testing.T, fn *goast.FuncDecl, nodes map[string][]string) {
+	t.Helper()
+
+	// Cases of the type switch and the fields referenced in them.
+	cases := make(map[string]map[string]bool)
+
+	goast.Inspect(fn.Body, func(n goast.Node) bool {
+		clause, ok := n.(*goast.CaseClause)
+		if !ok {
+			return true
+		}
+
+		refs := make(map[string]bool)
+		for _, stmt := range clause.Body {
+			goast.Inspect(stmt, func(n goast.Node) bool {
+				if sel, ok := n.(*goast.SelectorExpr); ok {
+					if x, ok := sel.X.(*goast.Ident); ok && x.Name == "n" {
+						refs[sel.Sel.Name] = true
+					}
+				}
+				return true
+			})
+		}
+
+		for _, expr := range clause.List {
+			if ident, ok := expr.(*goast.Ident); ok {
+				cases[ident.Name] = refs
+			}
+		}
+		return false
+	})
+
+	for name, fields := range nodes {
+		refs, found := cases[name]
+		if !found {
+			t.Errorf("%s doesn't handle node type %s", fn.Name.Name, name)
+			continue
+		}
+		for _, field := range fields {
+			if !refs[field] {
+				t.Errorf("%s doesn't visit %s.%s", fn.Name.Name, name, field)
+			}
+		}
+	}
+
+	for name := range cases {
+		if _, found := nodes[name]; !found {
+			t.Errorf("%s handles unknown node type %s", fn.Name.Name, name)
+		}
+	}
+}
+
+// isNodeMethod tells if the signature is that of Node.Node method.
+func isNodeMethod(sig *goast.FuncType) bool {
+	if sig.Params.NumFields() != 0 || sig.Results.NumFields() != 1 {
+		return false
+	}
+	ident, ok := sig.Results.List[0].Type.(*goast.Ident)
+	return ok && ident.Name == "string"
+}
+
+func receiverTypeName(expr goast.Expr) string {
+	if star, ok := expr.(*goast.StarExpr); ok {
+		expr = star.X
+	}
+	return expr.(*goast.Ident).Name
+}
+
+// isChildFieldType tells if a struct field type is a node, a pointer to a
+// node, a slice of nodes, or a node interface.
+func isChildFieldType(expr goast.Expr, nodes map[string][]string) bool {
+	switch x := expr.(type) {
+	case *goast.StarExpr:
+		return isChildFieldType(x.X, nodes)
+	case *goast.ArrayType:
+		return isChildFieldType(x.Elt, nodes)
+	case *goast.Ident:
+		if _, found := nodes[x.Name]; found {
+			return true
+		}
+		return strings.HasSuffix(x.Name, "Child")
+	}
+	return false
+}
@@ -29,9 +29,11 @@ func main() {
 	}
 
 	var (
-		old   = flag.Bool("old", false, "parse old language version")
-		diff  = flag.Bool("d", false, "display diffs instead of rewriting files")
-		write = flag.Bool("w", false, "write result to (source) file instead of stdout")
+		old     = flag.Bool("old", false, "parse old language version")
+		lenient = flag.Bool("lenient", false, "accept names which violate naming rules")
+		all     = flag.Bool("e", false, "report all errors")
+		diff    = flag.Bool("d", false, "display diffs instead of rewriting files")
+		write   = flag.Bool("w", false, "write result to (source) file instead of stdout")
 	)
 	flag.Parse()
 
@@ -42,7 +44,7 @@ func main() {
 	filename := flag.Arg(0)
 
 	err := pan.Recover(func() {
-		program(filename, *old, *diff, *write)
+		program(filename, *old, *lenient, *all, *diff, *write)
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, source.ErrorWithPositionPrefix(err, filename))
@@ -50,15 +52,32 @@ func main() {
 	}
 }
 
-func program(filename string, old, diff, write bool) {
+func program(filename string, old, lenient, all, diff, write bool) {
 	pos := source.Location(filename)
-	input := string(Must(os.ReadFile(filename)))
 
 	var parsed []ast.FileChild
 	if !old {
-		parsed = Must(parse.File(Must(lex.File(pos, input))))
+		f := Must(os.Open(filename))
+		defer f.Close()
+
+		s := lex.NewScanner(pos, f)
+		if lenient {
+			s.Mode |= lex.LenientNames
+		}
+
+		var mode parse.Mode
+		if all {
+			s.Mode |= lex.AllErrors
+			mode |= parse.AllErrors
+		}
+
+		parsed = Must(parse.StreamMode(s, mode))
+
+		if s.CRLF() {
+			fmt.Fprintf(os.Stderr, "%s: converting CRLF line endings to LF\n", filename)
+		}
 	} else {
-		parsed = Must(revise.File(pos, input))
+		parsed = Must(revise.File(pos, string(Must(os.ReadFile(filename)))))
 	}
 
 	output := format.File(parsed)
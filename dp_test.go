@@ -6,13 +6,17 @@ package dp_test
 import (
 	"os"
 	"path"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/tsavola/dp/ast"
 	"github.com/tsavola/dp/format"
+	"github.com/tsavola/dp/internal/revise"
 	"github.com/tsavola/dp/lex"
 	"github.com/tsavola/dp/parse"
 	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
 
 	. "github.com/tsavola/dp/internal/pan/mustcheck"
 )
@@ -57,7 +61,152 @@ func Test(t *testing.T) {
 				if false {
 					t.Logf("formatted:\n%s", formatted)
 				}
+
+				expect := input
+				if b, err := os.ReadFile(strings.TrimSuffix(filename, ".dp") + ".golden"); err == nil {
+					expect = string(b)
+				}
+				if formatted != expect {
+					t.Errorf("formatted:\n%s", formatted)
+				}
 			})
 		}
 	}
 }
+
+func TestAllErrors(t *testing.T) {
+	const input = `answer = 42
+
+broken() {
+	x := := 1
+	y := 2 $ 3
+	return
+}
+
+fine(x I64) I64 {
+	return   x
+}
+
+labels() {
+	break a
+	continue b
+}
+
+bad = = 1
+`
+
+	tokens, lexErr := lex.FileMode(source.Location("input"), input, lex.AllErrors)
+	if n := len(lexErr.(source.ErrorList)); n != 1 {
+		t.Errorf("%d lexical errors", n)
+	}
+
+	parsed, err := parse.FileMode(tokens, parse.AllErrors)
+	if n := len(err.(source.ErrorList)); n != 4 {
+		t.Errorf("%d syntax errors", n)
+	}
+
+	var bad []string
+	for _, node := range parsed {
+		switch node := node.(type) {
+		case ast.BadDecl:
+			bad = append(bad, node.Source)
+		case ast.FunctionDef:
+			for _, node := range node.Body {
+				if x, ok := node.(ast.BadStmt); ok {
+					bad = append(bad, x.Source)
+				}
+			}
+		}
+	}
+	if want := []string{"x := := 1", "y := 2 $ 3", "bad = = 1"}; !slices.Equal(bad, want) {
+		t.Errorf("bad nodes: %q", bad)
+	}
+
+	formatted := string(format.File(parsed))
+	if !strings.Contains(formatted, "\tx := := 1\n\ty := 2 $ 3\n") {
+		t.Errorf("bad statements not preserved:\n%s", formatted)
+	}
+	if !strings.Contains(formatted, "fine(x I64) I64 {\n\treturn x\n}\n") {
+		t.Errorf("intact function not formatted:\n%s", formatted)
+	}
+}
+
+func TestRevise(t *testing.T) {
+	for _, x := range []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			"else_if",
+			"sign(x I64) I64 {\n\tif x < 0 {\n\t\treturn -1\n\t} else {\n\t\tif x == 0 {\n\t\t\treturn 0\n\t\t} else {\n\t\t\treturn 1\n\t\t}\n\t}\n}\n",
+			"sign(x I64) I64 {\n\tif x < 0 {\n\t\treturn -1\n\t} else if x == 0 {\n\t\treturn 0\n\t} else {\n\t\treturn 1\n\t}\n}\n",
+		},
+		{
+			"index",
+			"f() I64 {\n\tvalues[i] = count(items)\n\treturn lookup(values[i])\n}\n",
+			"f() I64 {\n\tvalues[i] = count(items)\n\treturn lookup(values[i])\n}\n",
+		},
+	} {
+		t.Run(x.name, func(t *testing.T) {
+			revised, err := revise.File(source.Location("input"), x.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted := string(format.File(revised)); formatted != x.expect {
+				t.Errorf("revised:\n%s", formatted)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	for _, e := range Must(os.ReadDir("testdata")) {
+		if !strings.HasSuffix(e.Name(), "_test.dp") {
+			continue
+		}
+		filename := path.Join("testdata", e.Name())
+
+		tokens := Must(lex.File(source.Location(filename), string(Must(os.ReadFile(filename)))))
+		parsed := Must(parse.File(tokens))
+
+		var expect []string
+		for _, tok := range tokens {
+			switch tok.Kind {
+			case token.Comment, token.BlockComment, token.DocComment:
+				expect = append(expect, tok.Source)
+			}
+		}
+
+		var (
+			comments []string
+			depth    int
+			prev     source.Position
+		)
+		for _, node := range parsed {
+			ast.Inspect(node, func(node ast.Node) bool {
+				if node == nil {
+					depth--
+					return false
+				}
+				depth++
+
+				if c, ok := node.(ast.Comment); ok {
+					if c.At.ByteOffset < prev.ByteOffset {
+						t.Errorf("%s: comment %q visited out of order", c.At, c.Source)
+					}
+					comments = append(comments, c.Source)
+					prev = c.At
+				}
+				return true
+			})
+		}
+
+		if depth != 0 {
+			t.Errorf("%s: unbalanced traversal depth %d", filename, depth)
+		}
+		if !slices.Equal(comments, expect) {
+			t.Errorf("%s: visited comments:\n%q\nexpected:\n%q", filename, comments, expect)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package format
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/tsavola/dp/ast"
@@ -11,19 +12,30 @@ import (
 
 func formatBlock(w writer, level, startLine int, nodes []ast.BlockChild) {
 	w.WriteString("{")
+	formatBody(w, level, startLine, nodes, false)
+	w.WriteString("\n")
+	indent(w, level-1)
+	w.WriteString("}")
+}
 
+// formatBody formats statements.  If inline is true, the first statement
+// continues the current line.
+func formatBody(w writer, level, startLine int, nodes []ast.BlockChild, inline bool) {
 	columnify := func(node ast.BlockChild) (values []string) {
 		ast.VisitBlockChild(node,
 			func(ast.Assign) {},
+			func(ast.BadStmt) {},
 			func(ast.Block) {},
 			func(ast.Break) {},
 			func(ast.Comment) {},
 			func(ast.Continue) {},
+			func(ast.Defer) {},
 			func(ast.Expression) {},
 			func(ast.For) {},
 			func(ast.If) {},
 			func(ast.Import) {},
 			func(ast.Return) {},
+			func(ast.Switch) {},
 			func(node ast.VariableDecl) { values = []string{strings.Join(node.Names, ", "), ":"} },
 			func(node ast.VariableDef) { values = []string{strings.Join(node.Names, ", "), ":="} },
 		)
@@ -36,13 +48,9 @@ func formatBlock(w writer, level, startLine int, nodes []ast.BlockChild) {
 	)
 
 	base := w.Len()
-	formatStatements(w, level, startLine, nodes, columnify, columnWidths, commentOffsets)
+	formatStatements(w, level, startLine, nodes, inline, columnify, columnWidths, commentOffsets)
 	w.Truncate(base)
-	formatStatements(w, level, startLine, nodes, columnify, columnWidths, commentOffsets)
-
-	w.WriteString("\n")
-	indent(w, level-1)
-	w.WriteString("}")
+	formatStatements(w, level, startLine, nodes, inline, columnify, columnWidths, commentOffsets)
 }
 
 func formatStatements(
@@ -50,6 +58,7 @@ func formatStatements(
 	level int,
 	startLine int,
 	nodes []ast.BlockChild,
+	inline bool,
 	columnify func(ast.BlockChild) []string,
 	columnWidths map[int][]*int,
 	commentOffsets map[int]*int,
@@ -57,18 +66,25 @@ func formatStatements(
 	prevLine := startLine
 
 	for i, node := range nodes {
-		indentNode(w, level, prevLine, node)
+		if i > 0 || !inline {
+			if loop, ok := node.(ast.For); ok && loop.Label != "" {
+				// Outdented label on its own line.
+				indentNode(w, level-1, prevLine, node)
+				w.WriteString(loop.Label)
+				w.WriteString(":\n")
+				indent(w, level)
+			} else {
+				indentNode(w, level, prevLine, node)
+			}
+		}
 
 		ast.VisitBlockChild(node,
 			func(node ast.Assign) {
-				for i, node := range node.Targets {
-					if i > 0 {
-						w.WriteString(", ")
-					}
-					formatAssignListChild(w, node)
-				}
-				w.WriteString(" = ")
-				formatExprList(w, level+1, node.At.Line, node.Values, false)
+				formatAssign(w, level, node, false)
+			},
+
+			func(node ast.BadStmt) {
+				w.WriteString(node.Source)
 			},
 
 			func(node ast.Block) {
@@ -77,6 +93,10 @@ func formatStatements(
 
 			func(node ast.Break) {
 				w.WriteString("break")
+				if node.Label != "" {
+					w.WriteString(" ")
+					w.WriteString(node.Label)
+				}
 			},
 
 			func(node ast.Comment) {
@@ -85,6 +105,15 @@ func formatStatements(
 
 			func(node ast.Continue) {
 				w.WriteString("continue")
+				if node.Label != "" {
+					w.WriteString(" ")
+					w.WriteString(node.Label)
+				}
+			},
+
+			func(node ast.Defer) {
+				w.WriteString("defer ")
+				formatExpr(w, level+1, node.Expr, 0, false)
 			},
 
 			func(node ast.Expression) {
@@ -92,23 +121,11 @@ func formatStatements(
 			},
 
 			func(node ast.For) {
-				w.WriteString("for ")
-				if node.Test != nil {
-					formatExpr(w, level+1, node.Test, 0, false)
-					w.WriteString(" ")
-				}
-				formatBlock(w, level+1, node.BodyAt.Line, node.Body)
+				formatFor(w, level, node)
 			},
 
 			func(node ast.If) {
-				w.WriteString("if ")
-				formatExpr(w, level+1, node.Test, 0, false)
-				w.WriteString(" ")
-				formatBlock(w, level+1, node.ThenAt.Line, node.Then)
-				if len(node.Else) > 0 {
-					w.WriteString(" else ")
-					formatBlock(w, level+1, node.ThenEndAt.Line, node.Else)
-				}
+				formatIf(w, level, node)
 			},
 
 			func(ast.Import) {},
@@ -121,6 +138,10 @@ func formatStatements(
 				formatExprList(w, level+1, node.At.Line, node.Values, false)
 			},
 
+			func(node ast.Switch) {
+				formatSwitch(w, level, node)
+			},
+
 			func(node ast.VariableDecl) {
 				formatColumns(w, columnify(node), columnWidths[node.At.Line])
 				w.WriteString(" ")
@@ -147,13 +168,232 @@ func formatStatements(
 	}
 }
 
+// formatAssign parenthesizes the values in loop header if necessary.
+func formatAssign(w writer, level int, node ast.Assign, header bool) {
+	for i, node := range node.Targets {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		formatAssignListChild(w, node)
+	}
+	w.WriteString(" ")
+	if node.Op != 0 {
+		w.WriteString(node.Op.String())
+	}
+	w.WriteString("= ")
+	formatExprList(w, level+1, node.At.Line, node.Values, header && hasStructLit(node.Values...))
+}
+
+func formatFor(w writer, level int, node ast.For) {
+	w.WriteString("for ")
+
+	switch {
+	case node.Range != nil:
+		w.WriteString(strings.Join(node.Range.Names, ", "))
+		w.WriteString(" := range ")
+		formatHeaderExpr(w, level, node.Range.Expr)
+		w.WriteString(" ")
+
+	case node.Clauses():
+		if node.Init != nil {
+			formatSimpleStatement(w, level, node.Init)
+		}
+		w.WriteString("; ")
+		if node.Test != nil {
+			formatHeaderExpr(w, level, node.Test)
+		}
+		w.WriteString(";")
+		if node.Post != nil {
+			w.WriteString(" ")
+			formatSimpleStatement(w, level, node.Post)
+		}
+		w.WriteString(" ")
+
+	case node.Test != nil:
+		formatHeaderExpr(w, level, node.Test)
+		w.WriteString(" ")
+	}
+
+	formatBlock(w, level+1, node.BodyAt.Line, node.Body)
+}
+
+// formatSimpleStatement formats initialization or post statement of loop.
+func formatSimpleStatement(w writer, level int, node ast.BlockChild) {
+	switch node := node.(type) {
+	case ast.Assign:
+		formatAssign(w, level, node, true)
+
+	case ast.Expression:
+		formatHeaderExpr(w, level, node.Expr)
+
+	case ast.VariableDef:
+		w.WriteString(strings.Join(node.Names, ", "))
+		w.WriteString(" := ")
+		formatExprList(w, level+1, node.At.Line, node.Values, hasStructLit(node.Values...))
+
+	default:
+		panic("unexpected simple statement node type")
+	}
+}
+
+// formatHeaderExpr formats the expression of if, for or switch header.  It's
+// parenthesized if it contains a struct literal outside of brackets.
+func formatHeaderExpr(w writer, level int, node ast.ExprChild) {
+	parens := hasStructLit(ast.Expression{node})
+	if parens {
+		w.WriteString("(")
+	}
+	formatExpr(w, level+1, node, 0, false)
+	if parens {
+		w.WriteString(")")
+	}
+}
+
+// hasStructLit tells if a struct literal is found outside of brackets.
+func hasStructLit(nodes ...ast.ExprListChild) bool {
+	for _, node := range nodes {
+		if x, ok := node.(ast.Expression); ok && exprHasStructLit(x.Expr) {
+			return true
+		}
+	}
+	return false
+}
+
+func exprHasStructLit(node ast.ExprChild) bool {
+	switch node := node.(type) {
+	case ast.Address:
+		return exprHasStructLit(node.Expr)
+	case ast.Binary:
+		return exprHasStructLit(node.Left) || exprHasStructLit(node.Right)
+	case ast.Call:
+		return exprHasStructLit(node.Func)
+	case ast.Clone:
+		return exprHasStructLit(node.Expr)
+	case ast.CompositeLit:
+		return node.Type.Item == nil
+	case ast.FieldSelect:
+		return exprHasStructLit(node.Expr)
+	case ast.Index:
+		return exprHasStructLit(node.Expr)
+	case ast.PointerDereference:
+		return exprHasStructLit(node.Expr)
+	case ast.Slice:
+		return exprHasStructLit(node.Expr)
+	case ast.Unary:
+		return exprHasStructLit(node.Expr)
+	default:
+		return false
+	}
+}
+
+func formatIf(w writer, level int, node ast.If) {
+	w.WriteString("if ")
+	formatHeaderExpr(w, level, node.Test)
+	w.WriteString(" ")
+	formatBlock(w, level+1, node.ThenAt.Line, node.Then)
+
+	switch {
+	case node.ElseIf:
+		w.WriteString(" else ")
+		formatIf(w, level, node.Else[0].(ast.If))
+
+	case len(node.Else) > 0:
+		w.WriteString(" else ")
+		formatBlock(w, level+1, node.ThenEndAt.Line, node.Else)
+	}
+}
+
+// formatSwitch aligns the statements of consecutive cases which start on the
+// same line as their labels.
+func formatSwitch(w writer, level int, node ast.Switch) {
+	w.WriteString("switch ")
+	formatHeaderExpr(w, level, node.Value)
+	w.WriteString(" {")
+
+	columnify := func(node ast.CaseListChild) (values []string) {
+		if c, ok := node.(ast.Case); ok && caseInline(c) {
+			values = []string{caseLabel(c), ""}
+		}
+		return
+	}
+
+	columnWidths := getColumnWidths(node.Cases, columnify)
+
+	prevLine := node.BodyAt.Line
+
+	for _, node := range node.Cases {
+		indentNode(w, level, prevLine, node)
+
+		ast.VisitCaseListChild(node,
+			func(node ast.Case) {
+				inline := caseInline(node)
+				if inline {
+					formatColumns(w, columnify(node), columnWidths[node.At.Line])
+				} else {
+					w.WriteString(caseLabel(node))
+				}
+				formatBody(w, level+1, node.At.Line, node.Body, inline)
+			},
+
+			func(node ast.Comment) {
+				formatCommentAlone(w, level, node)
+			},
+		)
+
+		prevLine = node.End().Line
+	}
+
+	w.WriteString("\n")
+	indent(w, level)
+	w.WriteString("}")
+}
+
+// caseInline reports if the body consists of a single statement which is on
+// the same line as the label.
+func caseInline(node ast.Case) bool {
+	if len(node.Body) == 0 || node.Body[0].Pos().Line != node.At.Line {
+		return false
+	}
+
+	statements := 0
+	for _, x := range node.Body {
+		if _, ok := x.(ast.Comment); !ok {
+			statements++
+		}
+	}
+	if statements != 1 {
+		return false
+	}
+
+	switch first := node.Body[0].(type) {
+	case ast.Comment:
+		return false
+	case ast.For:
+		return first.Label == ""
+	default:
+		return true
+	}
+}
+
+func caseLabel(node ast.Case) string {
+	if node.Default {
+		return "default:"
+	}
+
+	w := writer{new(bytes.Buffer)}
+	w.WriteString("case ")
+	formatExprListOneLine(w, 0, node.Values)
+	w.WriteString(":")
+	return w.String()
+}
+
 func formatAssignListChild(w writer, node ast.AssignListChild) {
 	ast.VisitAssignListChild(node,
 		func(node ast.AssignerDereference) {
 			formatAssignerDereference(w, node)
 		},
 		func(node ast.Call) {
-			w.WriteString(node.Name.String())
+			formatAssignOperand(w, node.Func)
 			formatExprList(w, 0, node.Pos().Line, node.Args, true)
 		},
 		func(node ast.Cast) {
@@ -162,8 +402,13 @@ func formatAssignListChild(w writer, node ast.AssignListChild) {
 			formatExpr(w, 0, node.Expr, 0, true)
 			w.WriteString(")")
 		},
+		func(node ast.FieldSelect) {
+			formatAssignOperand(w, node.Expr)
+			w.WriteString(".")
+			w.WriteString(node.Name)
+		},
 		func(node ast.Index) {
-			w.WriteString(node.Name.String())
+			formatAssignOperand(w, node.Expr)
 			w.WriteString("[")
 			formatExpr(w, 0, node.Index, 0, true)
 			w.WriteString("]")
@@ -173,3 +418,13 @@ func formatAssignListChild(w writer, node ast.AssignListChild) {
 		},
 	)
 }
+
+// formatAssignOperand formats the operand of a postfix chain in assignment
+// target list.
+func formatAssignOperand(w writer, node ast.ExprChild) {
+	if x, ok := node.(ast.AssignListChild); ok {
+		formatAssignListChild(w, x)
+	} else {
+		formatOperand(w, 0, node, true)
+	}
+}
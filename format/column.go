@@ -20,6 +20,11 @@ func getColumnWidths[T ast.Node](nodes []T, getColumns func(T) []string) map[int
 				curr []*int
 			)
 
+			// Nodes on the same line are aligned with each other.
+			if same, ok := widths[line]; ok {
+				prev = same
+			}
+
 			for i, value := range values {
 				n := len(value) + 1 // Including space.
 
@@ -123,9 +123,59 @@ func formatComment(w writer, level int, node ast.Comment, nodeIndex int, offsets
 		offsets[nodeIndex] = off
 	}
 
-	formatCommentAlone(w, node)
+	formatCommentAlone(w, level, node)
 }
 
-func formatCommentAlone(w writer, node ast.Comment) {
-	w.WriteString(strings.TrimSpace(node.Source))
+func formatCommentAlone(w writer, level int, node ast.Comment) {
+	w.WriteString(commentText(level, node))
+}
+
+// commentText trims the comment.  Continuation lines of a block comment are
+// reindented: their common leading whitespace, up to the original indentation
+// of the comment, is replaced with level.
+func commentText(level int, node ast.Comment) string {
+	text := strings.TrimSpace(node.Source)
+	if !node.IsBlock() || !strings.Contains(text, "\n") {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	lines[0] = strings.TrimRight(lines[0], " \t\r")
+	common := ""
+	first := true
+
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			common = indent
+			first = false
+		} else {
+			common = commonPrefix(common, indent)
+		}
+	}
+
+	if n := node.At.Column - 1; len(common) > n {
+		common = common[:n]
+	}
+
+	for i, line := range lines[1:] {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			line = strings.Repeat("\t", level) + line[len(common):]
+		}
+		lines[1+i] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
 }
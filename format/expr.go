@@ -4,6 +4,8 @@
 package format
 
 import (
+	"strings"
+
 	"github.com/tsavola/dp/ast"
 )
 
@@ -48,7 +50,7 @@ func formatExpr(w writer, level int, node ast.ExprChild, parentPrec int, tight b
 		},
 
 		func(node ast.Call) {
-			w.WriteString(node.Name.String())
+			formatOperand(w, level, node.Func, tight)
 			formatExprList(w, level, node.Pos().Line, node.Args, true)
 		},
 
@@ -68,19 +70,54 @@ func formatExpr(w writer, level int, node ast.ExprChild, parentPrec int, tight b
 			formatExpr(w, level, node.Expr, ast.UltimatePrecedence, tight)
 		},
 
+		func(node ast.CompositeLit) {
+			formatCompositeLit(w, level, node)
+		},
+
 		func(node ast.Empty) {
 			w.WriteString("{}")
 		},
 
+		func(node ast.FieldSelect) {
+			formatOperand(w, level, node.Expr, tight)
+			w.WriteString(".")
+			w.WriteString(node.Name)
+		},
+
+		func(node ast.Float) {
+			w.WriteString(normalizeNumber(node.Source))
+		},
+
+		func(node ast.FuncLit) {
+			def := ast.FunctionDef{
+				At:          node.At,
+				Params:      node.Params,
+				ParamsEndAt: node.ParamsEndAt,
+				Results:     node.Results,
+				BodyAt:      node.BodyAt,
+				Body:        node.Body,
+				EndAt:       node.EndAt,
+			}
+
+			w.WriteString("func")
+			formatFunctionParams(w, level, def)
+			formatFunctionResults(w, level, def)
+			if body := trimFunctionBody(def); len(body) == 0 {
+				w.WriteString("{}")
+			} else {
+				formatBlock(w, level, node.BodyAt.Line, body)
+			}
+		},
+
 		func(node ast.Index) {
-			w.WriteString(node.Name.String())
+			formatOperand(w, level, node.Expr, tight)
 			w.WriteString("[")
 			formatExpr(w, level, node.Index, 0, false)
 			w.WriteString("]")
 		},
 
 		func(node ast.Integer) {
-			w.WriteString(node.Source)
+			w.WriteString(normalizeNumber(node.Source))
 		},
 
 		func(node ast.Nil) {
@@ -96,8 +133,21 @@ func formatExpr(w writer, level int, node ast.ExprChild, parentPrec int, tight b
 			w.WriteString(node.String())
 		},
 
+		func(node ast.Slice) {
+			formatOperand(w, level, node.Expr, tight)
+			w.WriteString("[")
+			if node.Low != nil {
+				formatExpr(w, level, node.Low, 0, true)
+			}
+			w.WriteString(":")
+			if node.High != nil {
+				formatExpr(w, level, node.High, 0, true)
+			}
+			w.WriteString("]")
+		},
+
 		func(node ast.String) {
-			w.WriteString(node.Source)
+			w.WriteString(strings.ReplaceAll(node.Source, "\r\n", "\n"))
 		},
 
 		func(node ast.Unary) {
@@ -114,6 +164,52 @@ func formatExpr(w writer, level int, node ast.ExprChild, parentPrec int, tight b
 	)
 }
 
+// formatOperand formats the operand of a call, index expression or field
+// selection.  Prefix and infix operator expressions are parenthesized, and so
+// are literals which can't be followed by a postfix operation.
+func formatOperand(w writer, level int, node ast.ExprChild, tight bool) {
+	for {
+		x, ok := node.(ast.Unary)
+		if !ok || x.Op != ast.OpIdentity {
+			break
+		}
+		node = x.Expr
+	}
+
+	switch node.(type) {
+	case ast.Address, ast.Binary, ast.Clone, ast.PointerDereference, ast.Unary,
+		ast.Boolean, ast.Character, ast.Empty, ast.Float, ast.Integer, ast.Nil, ast.String:
+		w.WriteString("(")
+		formatExpr(w, level, node, 0, tight)
+		w.WriteString(")")
+
+	default:
+		formatExpr(w, level, node, ast.UltimatePrecedence, tight)
+	}
+}
+
+// normalizeNumber converts base prefix and exponent marker to lower case.
+func normalizeNumber(s string) string {
+	exponent := "E"
+
+	if len(s) > 1 && s[0] == '0' {
+		switch s[1] {
+		case 'X':
+			s = "0x" + s[2:]
+		case 'O':
+			s = "0o" + s[2:]
+		case 'B':
+			s = "0b" + s[2:]
+		}
+
+		if s[1] == 'x' {
+			exponent = "P"
+		}
+	}
+
+	return strings.Replace(s, exponent, strings.ToLower(exponent), 1)
+}
+
 func formatAssignerDereference(w writer, node ast.AssignerDereference) {
 	w.WriteString("(")
 	w.WriteString(node.Name)
@@ -27,11 +27,14 @@ func File(nodes []ast.FileChild) []byte {
 		if i != importsIndex && g.node != nil {
 			var isImport bool
 			ast.VisitFileChild(*g.node,
+				func(ast.BadDecl) {},
 				func(ast.Comment) {},
 				func(ast.ConstantDef) {},
+				func(ast.EnumDef) {},
 				func(ast.FunctionDef) {},
 				func(ast.Import) { isImport = true },
 				func(ast.Imports) { isImport = true },
+				func(ast.InterfaceDef) {},
 				func(ast.TypeDef) {},
 			)
 			if isImport {
@@ -47,24 +50,30 @@ func File(nodes []ast.FileChild) []byte {
 			curr := g.node
 			if curr != nil && prev != nil {
 				ast.VisitFileChild(*prev,
+					func(ast.BadDecl) {},
 					func(ast.Comment) {},
 					func(prev ast.ConstantDef) {
 						ast.VisitFileChild(*curr,
+							func(ast.BadDecl) {},
 							func(ast.Comment) {},
 							func(curr ast.ConstantDef) {
 								if curr.At.Line-prev.EndAt.Line <= 1 && curr.Public == prev.Public {
 									gap = false
 								}
 							},
+							func(ast.EnumDef) {},
 							func(ast.FunctionDef) {},
 							func(ast.Import) {},
 							func(ast.Imports) {},
+							func(ast.InterfaceDef) {},
 							func(ast.TypeDef) {},
 						)
 					},
+					func(ast.EnumDef) {},
 					func(ast.FunctionDef) {},
 					func(ast.Import) {},
 					func(ast.Imports) {},
+					func(ast.InterfaceDef) {},
 					func(ast.TypeDef) {},
 				)
 			}
@@ -76,7 +85,7 @@ func File(nodes []ast.FileChild) []byte {
 
 		if i == importsIndex {
 			for _, node := range imports.head {
-				w.WriteString(strings.TrimSpace(node.Source))
+				w.WriteString(commentText(0, node))
 				w.WriteString("\n")
 			}
 
@@ -89,7 +98,7 @@ func File(nodes []ast.FileChild) []byte {
 
 				for _, node := range imp.head {
 					w.WriteString("\t")
-					w.WriteString(strings.TrimSpace(node.Source))
+					w.WriteString(commentText(1, node))
 					w.WriteString("\n")
 				}
 
@@ -117,7 +126,7 @@ func File(nodes []ast.FileChild) []byte {
 
 					if imp.tail != nil {
 						w.WriteString(" ")
-						w.WriteString(strings.TrimSpace(imp.tail.Source))
+						w.WriteString(commentText(1, *imp.tail))
 					}
 					w.WriteString("\n")
 				}
@@ -127,12 +136,17 @@ func File(nodes []ast.FileChild) []byte {
 		}
 
 		for _, node := range g.head {
-			w.WriteString(strings.TrimSpace(node.Source))
+			w.WriteString(commentText(0, node))
 			w.WriteString("\n")
 		}
 
 		if g.node != nil {
 			ast.VisitFileChild(*g.node,
+				func(node ast.BadDecl) {
+					w.WriteString(node.Source)
+					w.WriteString("\n")
+				},
+
 				func(ast.Comment) {},
 
 				func(node ast.ConstantDef) {
@@ -145,6 +159,11 @@ func File(nodes []ast.FileChild) []byte {
 					w.WriteString("\n")
 				},
 
+				func(node ast.EnumDef) {
+					formatEnumDef(w, node)
+					w.WriteString("\n")
+				},
+
 				func(node ast.FunctionDef) {
 					if node.Public {
 						w.WriteString("pub ")
@@ -159,8 +178,9 @@ func File(nodes []ast.FileChild) []byte {
 						w.WriteString(") ")
 					}
 					w.WriteString(node.FuncName)
-					formatFunctionParams(w, node)
-					formatFunctionResults(w, node)
+					formatTypeParams(w, node.TypeParams)
+					formatFunctionParams(w, 1, node)
+					formatFunctionResults(w, 1, node)
 					if body := trimFunctionBody(node); len(body) == 0 {
 						w.WriteString("{}")
 					} else {
@@ -172,6 +192,11 @@ func File(nodes []ast.FileChild) []byte {
 				func(ast.Import) {},
 				func(ast.Imports) {},
 
+				func(node ast.InterfaceDef) {
+					formatInterfaceDef(w, node)
+					w.WriteString("\n")
+				},
+
 				func(node ast.TypeDef) {
 					empty := true
 					for _, node := range node.Fields {
@@ -191,7 +216,29 @@ func File(nodes []ast.FileChild) []byte {
 	return w.Bytes()
 }
 
-func formatFunctionParams(w writer, def ast.FunctionDef) {
+func formatTypeParams(w writer, names []string) {
+	if len(names) > 0 {
+		w.WriteString("[")
+		w.WriteString(strings.Join(names, ", "))
+		w.WriteString("]")
+	}
+}
+
+// formatParamsOneLine omits repeated types of consecutive parameters.
+func formatParamsOneLine(w writer, params []ast.Parameter) {
+	for i, param := range params {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(param.ParamName)
+		if i == len(params)-1 || !param.Type.Type.Equal(params[i+1].Type.Type) {
+			w.WriteString(" ")
+			w.WriteString(param.Type.String())
+		}
+	}
+}
+
+func formatFunctionParams(w writer, level int, def ast.FunctionDef) {
 	var (
 		comments bool
 		params   []ast.Parameter
@@ -206,16 +253,7 @@ func formatFunctionParams(w writer, def ast.FunctionDef) {
 	w.WriteString("(")
 
 	if !comments && (len(params) == 0 || params[0].At.Line == def.At.Line) {
-		for i, param := range params {
-			if i > 0 {
-				w.WriteString(", ")
-			}
-			w.WriteString(param.ParamName)
-			if i == len(params)-1 || !param.Type.Type.Equal(params[i+1].Type.Type) {
-				w.WriteString(" ")
-				w.WriteString(param.Type.String())
-			}
-		}
+		formatParamsOneLine(w, params)
 	} else {
 		columnify := func(node ast.ParamListChild) (values []string) {
 			ast.VisitParamListChild(node,
@@ -231,25 +269,26 @@ func formatFunctionParams(w writer, def ast.FunctionDef) {
 		)
 
 		base := w.Len()
-		formatFunctionParamsMultiLine(w, def, columnify, columnWidths, commentOffsets)
+		formatFunctionParamsMultiLine(w, level, def, columnify, columnWidths, commentOffsets)
 		w.Truncate(base)
-		formatFunctionParamsMultiLine(w, def, columnify, columnWidths, commentOffsets)
+		formatFunctionParamsMultiLine(w, level, def, columnify, columnWidths, commentOffsets)
 
 		w.WriteString("\n")
+		indent(w, level-1)
 	}
 
 	w.WriteString(") ")
 }
 
-func formatFunctionParamsMultiLine(w writer, def ast.FunctionDef, columnify func(ast.ParamListChild) []string, columnWidths map[int][]*int, commentOffsets map[int]*int) {
+func formatFunctionParamsMultiLine(w writer, level int, def ast.FunctionDef, columnify func(ast.ParamListChild) []string, columnWidths map[int][]*int, commentOffsets map[int]*int) {
 	prevLine := def.At.Line
 
 	for i, node := range def.Params {
-		indentNode(w, 1, prevLine, node)
+		indentNode(w, level, prevLine, node)
 
 		ast.VisitParamListChild(node,
 			func(node ast.Comment) {
-				formatComment(w, 1, node, i, commentOffsets)
+				formatComment(w, level, node, i, commentOffsets)
 			},
 
 			func(node ast.Parameter) {
@@ -262,7 +301,7 @@ func formatFunctionParamsMultiLine(w writer, def ast.FunctionDef, columnify func
 	}
 }
 
-func formatFunctionResults(w writer, def ast.FunctionDef) {
+func formatFunctionResults(w writer, level int, def ast.FunctionDef) {
 	var (
 		comments bool
 		specs    []ast.TypeSpec
@@ -300,23 +339,25 @@ func formatFunctionResults(w writer, def ast.FunctionDef) {
 		commentOffsets := make(map[int]*int)
 
 		base := w.Len()
-		formatFunctionResultsMultiLine(w, def, commentOffsets)
+		formatFunctionResultsMultiLine(w, level, def, commentOffsets)
 		w.Truncate(base)
-		formatFunctionResultsMultiLine(w, def, commentOffsets)
+		formatFunctionResultsMultiLine(w, level, def, commentOffsets)
 
-		w.WriteString("\n) ")
+		w.WriteString("\n")
+		indent(w, level-1)
+		w.WriteString(") ")
 	}
 }
 
-func formatFunctionResultsMultiLine(w writer, def ast.FunctionDef, commentOffsets map[int]*int) {
+func formatFunctionResultsMultiLine(w writer, level int, def ast.FunctionDef, commentOffsets map[int]*int) {
 	prevLine := def.ParamsEndAt.Line
 
 	for i, node := range def.Results {
-		indentNode(w, 1, prevLine, node)
+		indentNode(w, level, prevLine, node)
 
 		ast.VisitTypeListChild(node,
 			func(node ast.Comment) {
-				formatComment(w, 1, node, i, commentOffsets)
+				formatComment(w, level, node, i, commentOffsets)
 			},
 
 			func(node ast.TypeSpec) {
@@ -342,12 +383,14 @@ func trimFunctionBody(def ast.FunctionDef) []ast.BlockChild {
 
 		ast.VisitBlockChild(nodes[i],
 			func(ast.Assign) {},
+			func(ast.BadStmt) {},
 			func(ast.Block) {},
 			func(ast.Break) {},
 			func(node ast.Comment) {
 				done = false
 			},
 			func(ast.Continue) {},
+			func(ast.Defer) {},
 			func(ast.Expression) {},
 			func(ast.For) {},
 			func(ast.If) {},
@@ -358,6 +401,7 @@ func trimFunctionBody(def ast.FunctionDef) []ast.BlockChild {
 					done = false
 				}
 			},
+			func(ast.Switch) {},
 			func(ast.VariableDecl) {},
 			func(ast.VariableDef) {},
 		)
@@ -370,11 +414,200 @@ func trimFunctionBody(def ast.FunctionDef) []ast.BlockChild {
 	return nodes
 }
 
+// formatEnumDef aligns the explicit values of members on consecutive lines.
+func formatEnumDef(w writer, node ast.EnumDef) {
+	if node.Public {
+		w.WriteString("pub ")
+	}
+	w.WriteString(node.TypeName)
+	w.WriteString(" enum {")
+
+	if len(node.Members) > 0 {
+		columnify := func(node ast.EnumListChild) (values []string) {
+			ast.VisitEnumListChild(node,
+				func(ast.Comment) {},
+				func(node ast.EnumMember) {
+					if node.Value != nil {
+						w := writer{new(bytes.Buffer)}
+						w.WriteString("= ")
+						formatExpr(w, 1, node.Value, 0, false)
+						values = []string{node.MemberName, w.String()}
+					}
+				},
+			)
+			return
+		}
+
+		var (
+			columnWidths   = getColumnWidths(node.Members, columnify)
+			commentOffsets = make(map[int]*int)
+		)
+
+		base := w.Len()
+		formatEnumMembers(w, node, columnify, columnWidths, commentOffsets)
+		w.Truncate(base)
+		formatEnumMembers(w, node, columnify, columnWidths, commentOffsets)
+
+		w.WriteString("\n")
+	}
+
+	w.WriteString("}")
+}
+
+func formatEnumMembers(w writer, def ast.EnumDef, columnify func(ast.EnumListChild) []string, columnWidths map[int][]*int, commentOffsets map[int]*int) {
+	prevLine := def.At.Line
+
+	for i, node := range def.Members {
+		indentNode(w, 1, prevLine, node)
+
+		ast.VisitEnumListChild(node,
+			func(node ast.Comment) { formatComment(w, 1, node, i, commentOffsets) },
+			func(node ast.EnumMember) {
+				if node.Value != nil {
+					formatColumns(w, columnify(node), columnWidths[node.At.Line])
+				} else {
+					w.WriteString(node.MemberName)
+				}
+			},
+		)
+
+		prevLine = node.End().Line
+	}
+}
+
+// formatInterfaceDef aligns the names and results of method signatures on
+// consecutive lines.
+func formatInterfaceDef(w writer, node ast.InterfaceDef) {
+	if node.Public {
+		w.WriteString("pub ")
+	}
+	w.WriteString(node.TypeName)
+	formatTypeParams(w, node.TypeParams)
+	w.WriteString(" interface {")
+
+	if len(node.Methods) > 0 {
+		columnify := func(node ast.MethodListChild) (values []string) {
+			ast.VisitMethodListChild(node,
+				func(ast.Comment) {},
+				func(node ast.MethodSig) {
+					if !methodSigHasComments(node) {
+						values = methodSigColumns(node)
+					}
+				},
+			)
+			return
+		}
+
+		var (
+			columnWidths   = getColumnWidths(node.Methods, columnify)
+			commentOffsets = make(map[int]*int)
+		)
+
+		base := w.Len()
+		formatInterfaceMethods(w, node, columnify, columnWidths, commentOffsets)
+		w.Truncate(base)
+		formatInterfaceMethods(w, node, columnify, columnWidths, commentOffsets)
+
+		w.WriteString("\n")
+	}
+
+	w.WriteString("}")
+}
+
+func formatInterfaceMethods(w writer, def ast.InterfaceDef, columnify func(ast.MethodListChild) []string, columnWidths map[int][]*int, commentOffsets map[int]*int) {
+	prevLine := def.At.Line
+
+	for i, node := range def.Methods {
+		indentNode(w, 1, prevLine, node)
+
+		ast.VisitMethodListChild(node,
+			func(node ast.Comment) { formatComment(w, 1, node, i, commentOffsets) },
+			func(node ast.MethodSig) {
+				if methodSigHasComments(node) {
+					formatMethodSigMultiLine(w, node)
+				} else {
+					formatColumns(w, columnify(node), columnWidths[node.At.Line])
+				}
+			},
+		)
+
+		prevLine = node.End().Line
+	}
+}
+
+// methodSigHasComments reports if there are comments among parameters or
+// results.  Such signatures can't be formatted on a single line.
+func methodSigHasComments(node ast.MethodSig) bool {
+	for _, node := range node.Params {
+		if ast.IsComment(node) {
+			return true
+		}
+	}
+	for _, node := range node.Results {
+		if ast.IsComment(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMethodSigMultiLine formats the signature like a function definition.
+func formatMethodSigMultiLine(w writer, node ast.MethodSig) {
+	def := ast.FunctionDef{
+		At:          node.At,
+		Params:      node.Params,
+		ParamsEndAt: node.ParamsEndAt,
+		Results:     node.Results,
+		EndAt:       node.EndAt,
+	}
+
+	w.WriteString("(" + node.Receiver.String() + ") ")
+	w.WriteString(node.MethodName)
+	formatFunctionParams(w, 2, def)
+	formatFunctionResults(w, 2, def)
+	w.Truncate(w.Len() - 1) // Space before function body.
+}
+
+// methodSigColumns returns receiver, name with parameters, and results.
+func methodSigColumns(node ast.MethodSig) []string {
+	var params []ast.Parameter
+	for _, node := range node.Params {
+		ast.VisitParamListChild(node,
+			func(ast.Comment) {},
+			func(node ast.Parameter) { params = append(params, node) },
+		)
+	}
+
+	var results []string
+	for _, node := range node.Results {
+		ast.VisitTypeListChild(node,
+			func(ast.Comment) {},
+			func(node ast.TypeSpec) { results = append(results, node.Type.String()) },
+		)
+	}
+
+	w := writer{new(bytes.Buffer)}
+	w.WriteString(node.MethodName)
+	w.WriteString("(")
+	formatParamsOneLine(w, params)
+	w.WriteString(")")
+
+	var result string
+	if len(results) == 1 {
+		result = results[0]
+	} else {
+		result = "(" + strings.Join(results, ", ") + ")"
+	}
+
+	return []string{"(" + node.Receiver.String() + ")", w.String(), result}
+}
+
 func formatTypeDefBody(w writer, node ast.TypeDef, empty bool) {
 	if node.Public {
 		w.WriteString("pub ")
 	}
 	w.WriteString(node.TypeName)
+	formatTypeParams(w, node.TypeParams)
 	w.WriteString(" {")
 
 	if !empty {
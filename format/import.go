@@ -62,6 +62,12 @@ func mergeImports(groups []commentedNode[ast.FileChild]) (index int, imports com
 	for i, g := range groups {
 		if g.node != nil {
 			ast.VisitFileChild(*g.node,
+				func(ast.BadDecl) {
+					if firstSubstanceIndex < 0 {
+						firstSubstanceIndex = i
+					}
+				},
+
 				func(ast.Comment) {},
 
 				func(node ast.ConstantDef) {
@@ -70,6 +76,12 @@ func mergeImports(groups []commentedNode[ast.FileChild]) (index int, imports com
 					}
 				},
 
+				func(ast.EnumDef) {
+					if firstSubstanceIndex < 0 {
+						firstSubstanceIndex = i
+					}
+				},
+
 				func(node ast.FunctionDef) {
 					if firstSubstanceIndex < 0 {
 						firstSubstanceIndex = i
@@ -95,6 +107,12 @@ func mergeImports(groups []commentedNode[ast.FileChild]) (index int, imports com
 					list = append(list, splitCommentedNodes[ast.ImportListChild, ast.Import](node.Imports, false)...)
 				},
 
+				func(ast.InterfaceDef) {
+					if firstSubstanceIndex < 0 {
+						firstSubstanceIndex = i
+					}
+				},
+
 				func(node ast.TypeDef) {
 					if firstSubstanceIndex < 0 {
 						firstSubstanceIndex = i
@@ -132,15 +150,18 @@ func appendImportsFromBlock(list []commentedNode[ast.Import], nodes []ast.BlockC
 	for _, node := range nodes {
 		ast.VisitBlockChild(node,
 			func(ast.Assign) {},
+			func(ast.BadStmt) {},
 			func(node ast.Block) { list = appendImportsFromBlock(list, node.Body) },
 			func(ast.Break) {},
 			func(ast.Comment) {},
 			func(ast.Continue) {},
+			func(ast.Defer) {},
 			func(ast.Expression) {},
 			func(ast.For) {},
 			func(ast.If) {},
 			func(node ast.Import) { list = append(list, commentedNode[ast.Import]{nil, &node, nil}) },
 			func(ast.Return) {},
+			func(ast.Switch) {},
 			func(ast.VariableDecl) {},
 			func(ast.VariableDef) {},
 		)
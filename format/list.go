@@ -8,16 +8,56 @@ import (
 )
 
 func useMultipleLines[T ast.Node](startLine int, nodes ...T) bool {
-	for _, node := range nodes {
-		if node.End().Line > startLine || ast.IsComment(node) {
+	for i, node := range nodes {
+		if node.End().Line > startLine || (ast.IsComment(node) && !isInlineComment(nodes, i)) {
 			return true
 		}
 	}
 	return false
 }
 
+// isInlineComment tells if nodes[i] is a block comment which is followed by
+// another node on the same line.
+func isInlineComment[T ast.Node](nodes []T, i int) bool {
+	c, ok := ast.Node(nodes[i]).(ast.Comment)
+	return ok && c.IsBlock() && i+1 < len(nodes) && nodes[i+1].Pos().Line == c.End().Line
+}
+
+// indentListNode is like indentNode, but keeps a node on the same line with a
+// preceding inline comment.
+func indentListNode[T ast.Node](w writer, level, prevLine int, nodes []T, i int) {
+	if i > 0 && isInlineComment(nodes, i-1) {
+		w.WriteString(" ")
+	} else {
+		indentNode(w, level, prevLine, nodes[i])
+	}
+}
+
+// endsWithLiteral tells if the list fits on the start line except for the
+// elements of a composite literal or the body of a function literal at the end,
+// possibly as the last argument of a call.
+func endsWithLiteral(startLine int, nodes []ast.ExprListChild) bool {
+	if len(nodes) == 0 || useMultipleLines(startLine, nodes[:len(nodes)-1]...) {
+		return false
+	}
+
+	x, ok := nodes[len(nodes)-1].(ast.Expression)
+	if !ok {
+		return false
+	}
+	switch lit := x.Expr.(type) {
+	case ast.Call:
+		return lit.Func.End().Line == startLine && endsWithLiteral(startLine, lit.Args)
+	case ast.CompositeLit:
+		return lit.At.Line == startLine
+	case ast.FuncLit:
+		return lit.BodyAt.Line == startLine
+	}
+	return false
+}
+
 func formatExprList(w writer, level, startLine int, nodes []ast.ExprListChild, forceParens bool) {
-	if useMultipleLines(startLine, nodes...) {
+	if useMultipleLines(startLine, nodes...) && !endsWithLiteral(startLine, nodes) {
 		w.WriteString("(")
 
 		commentOffsets := make(map[int]*int)
@@ -35,7 +75,7 @@ func formatExprList(w writer, level, startLine int, nodes []ast.ExprListChild, f
 			w.WriteString("(")
 		}
 
-		formatExprListOneLine(w, nodes)
+		formatExprListOneLine(w, level, nodes)
 
 		if forceParens {
 			w.WriteString(")")
@@ -43,26 +83,36 @@ func formatExprList(w writer, level, startLine int, nodes []ast.ExprListChild, f
 	}
 }
 
-func formatExprListOneLine(w writer, nodes []ast.ExprListChild) {
+func formatExprListOneLine(w writer, level int, nodes []ast.ExprListChild) {
 	for i, node := range nodes {
-		if i > 0 {
-			w.WriteString(", ")
-		}
+		writeListSeparator(w, nodes, i)
 
 		ast.VisitExprListChild(node,
 			func(node ast.AssignerDereference) { formatAssignerDereference(w, node) },
-			func(ast.Comment) {},
-			func(node ast.Expression) { formatExpr(w, 0, node.Expr, 0, false) },
+			func(node ast.Comment) { formatCommentAlone(w, level, node) },
+			func(node ast.Expression) { formatExpr(w, level, node.Expr, 0, false) },
 		)
 	}
 }
 
+// writeListSeparator writes comma before nodes[i] on a single line, unless it
+// follows an inline comment.
+func writeListSeparator[T ast.Node](w writer, nodes []T, i int) {
+	switch {
+	case i == 0:
+	case ast.IsComment(nodes[i-1]):
+		w.WriteString(" ")
+	default:
+		w.WriteString(", ")
+	}
+}
+
 func formatExprListMultiLine(w writer, level, startLine int, nodes []ast.ExprListChild, commentOffsets map[int]*int) {
 	first := true
 	prevLine := startLine
 
 	for i, node := range nodes {
-		indentNode(w, level, prevLine, node)
+		indentListNode(w, level, prevLine, nodes, i)
 
 		ast.VisitExprListChild(node,
 			func(node ast.AssignerDereference) {
@@ -71,8 +121,73 @@ func formatExprListMultiLine(w writer, level, startLine int, nodes []ast.ExprLis
 			},
 
 			func(node ast.Comment) {
-				if first {
-					formatCommentAlone(w, node)
+				if first || isInlineComment(nodes, i) {
+					formatCommentAlone(w, level, node)
+				} else {
+					formatComment(w, 1, node, i, commentOffsets)
+				}
+			},
+
+			func(node ast.Expression) {
+				formatExpr(w, level+1, node.Expr, 0, false)
+				w.WriteString(",")
+			},
+		)
+
+		first = false
+		prevLine = node.End().Line
+	}
+}
+
+func formatCompositeLit(w writer, level int, node ast.CompositeLit) {
+	w.WriteString(node.Type.String())
+	w.WriteString("{")
+
+	if useMultipleLines(node.At.Line, node.Elems...) {
+		columnWidths := getColumnWidths(node.Elems, elemColumns)
+		commentOffsets := make(map[int]*int)
+
+		base := w.Len()
+		formatElemListMultiLine(w, level, node.At.Line, node.Elems, columnWidths, commentOffsets)
+		w.Truncate(base)
+		formatElemListMultiLine(w, level, node.At.Line, node.Elems, columnWidths, commentOffsets)
+
+		w.WriteString("\n")
+		indent(w, level-1)
+	} else {
+		formatElemListOneLine(w, node.Elems)
+	}
+
+	w.WriteString("}")
+}
+
+func formatElemListOneLine(w writer, nodes []ast.ElemListChild) {
+	for i, node := range nodes {
+		writeListSeparator(w, nodes, i)
+
+		ast.VisitElemListChild(node,
+			func(node ast.Comment) { formatCommentAlone(w, 0, node) },
+			func(node ast.Expression) { formatExpr(w, 0, node.Expr, 0, false) },
+			func(node ast.KeyValue) {
+				w.WriteString(node.Key)
+				w.WriteString(": ")
+				formatExpr(w, 0, node.Value, 0, false)
+			},
+		)
+	}
+}
+
+func formatElemListMultiLine(w writer, level, startLine int, nodes []ast.ElemListChild, columnWidths map[int][]*int, commentOffsets map[int]*int) {
+	first := true
+	prevLine := startLine
+
+	for i, node := range nodes {
+		indentListNode(w, level, prevLine, nodes, i)
+
+		ast.VisitElemListChild(node,
+			func(node ast.Comment) {
+				if first || isInlineComment(nodes, i) {
+					formatCommentAlone(w, level, node)
 				} else {
 					formatComment(w, 1, node, i, commentOffsets)
 				}
@@ -82,9 +197,23 @@ func formatExprListMultiLine(w writer, level, startLine int, nodes []ast.ExprLis
 				formatExpr(w, level+1, node.Expr, 0, false)
 				w.WriteString(",")
 			},
+
+			func(node ast.KeyValue) {
+				formatColumns(w, elemColumns(node), columnWidths[node.At.Line])
+				formatExpr(w, level+1, node.Value, 0, false)
+				w.WriteString(",")
+			},
 		)
 
 		first = false
 		prevLine = node.End().Line
 	}
 }
+
+// elemColumns aligns the values of keyed elements on consecutive lines.
+func elemColumns(node ast.ElemListChild) (values []string) {
+	if x, ok := node.(ast.KeyValue); ok {
+		values = []string{x.Key + ":", ""}
+	}
+	return
+}
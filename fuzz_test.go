@@ -13,6 +13,10 @@ import (
 )
 
 func Fuzz(f *testing.F) {
+	f.Add("f() {\n\tx := a[lo:hi]\n}\n")
+	f.Add("f() {\n\tx := a[:n - 1][i+1:]\n\ty := get()[:]\n}\n")
+	f.Add("f() {\n\tcopy(a[1:][0:2], (b + c)[:])\n}\n")
+
 	f.Fuzz(func(t *testing.T, input string) {
 		tokens, err := lex.File(source.Location(t.Name()), input)
 		if err != nil {
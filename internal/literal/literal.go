@@ -0,0 +1,189 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package literal decodes string and character literals.
+package literal
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Error describes an invalid part of literal source.
+type Error struct {
+	Offset int // Byte offset in literal source.
+	Msg    string
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+// Unquote decodes string literal source, including the quotes.  Carriage
+// returns of CRLF line endings are discarded.  Backquoted string is raw, except
+// that \` stands for a backquote.
+func Unquote(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", &Error{0, "invalid string literal"}
+	}
+
+	switch s[0] {
+	case '`':
+		var b strings.Builder
+
+		for i := 1; i < len(s)-1; {
+			switch {
+			case strings.HasPrefix(s[i:], "\r\n"):
+				i++
+
+			case s[i] == '\\' && i+1 < len(s)-1:
+				if s[i+1] != '`' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(s[i+1])
+				i += 2
+
+			case s[i] == '`':
+				return "", &Error{i, "unexpected quote in raw string literal"}
+
+			default:
+				b.WriteByte(s[i])
+				i++
+			}
+		}
+
+		return b.String(), nil
+
+	case '"':
+		var b strings.Builder
+
+		for i := 1; i < len(s)-1; {
+			if strings.HasPrefix(s[i:], "\r\n") {
+				i++
+				continue
+			}
+
+			c, byteValue, n, err := decode(s, i, '"')
+			if err != nil {
+				return "", err
+			}
+
+			if byteValue {
+				b.WriteByte(byte(c))
+			} else {
+				b.WriteRune(c)
+			}
+			i += n
+		}
+
+		return b.String(), nil
+
+	default:
+		return "", &Error{0, "invalid string literal"}
+	}
+}
+
+// UnquoteChar decodes character literal source, including the quotes.
+func UnquoteChar(s string) (rune, error) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return 0, &Error{0, "invalid character literal"}
+	}
+	if len(s) == 2 {
+		return 0, &Error{0, "empty character literal"}
+	}
+
+	c, _, n, err := decode(s, 1, '\'')
+	if err != nil {
+		return 0, err
+	}
+	if 1+n != len(s)-1 {
+		return 0, &Error{1 + n, "character literal contains multiple characters"}
+	}
+
+	return c, nil
+}
+
+// decode the character or escape sequence at s[i].  The value is a byte
+// instead of a code point if byteValue is true.
+func decode(s string, i int, quote byte) (c rune, byteValue bool, n int, err error) {
+	if s[i] != '\\' {
+		c, n = utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && n <= 1 {
+			err = &Error{i, "invalid UTF-8 encoding"}
+		} else if c == rune(quote) {
+			err = &Error{i, "unescaped quote"}
+		}
+		return
+	}
+
+	if i+1 == len(s)-1 {
+		err = &Error{i, "incomplete escape sequence"}
+		return
+	}
+
+	switch s[i+1] {
+	case 'a':
+		c = '\a'
+	case 'b':
+		c = '\b'
+	case 'f':
+		c = '\f'
+	case 'n':
+		c = '\n'
+	case 'r':
+		c = '\r'
+	case 't':
+		c = '\t'
+	case 'v':
+		c = '\v'
+	case '\\', '\'', '"':
+		c = rune(s[i+1])
+
+	case 'x':
+		if i+4 > len(s)-1 || !isHex(s[i+2]) || !isHex(s[i+3]) {
+			err = &Error{i, "hexadecimal escape sequence requires two digits"}
+			return
+		}
+		c = hexValue(s[i+2])<<4 | hexValue(s[i+3])
+		return c, quote == '"', 4, nil
+
+	case 'u':
+		end := strings.IndexByte(s[i:len(s)-1], '}')
+		if i+2 >= len(s)-1 || s[i+2] != '{' || end < 0 {
+			err = &Error{i, `unicode escape sequence must be enclosed in \u{...}`}
+			return
+		}
+		digits := s[i+3 : i+end]
+		if len(digits) == 0 || len(digits) > 6 {
+			err = &Error{i, "unicode escape sequence requires one to six digits"}
+			return
+		}
+		for j := 0; j < len(digits); j++ {
+			if !isHex(digits[j]) {
+				err = &Error{i + 3 + j, "invalid digit in unicode escape sequence"}
+				return
+			}
+			c = c<<4 | hexValue(digits[j])
+		}
+		if !utf8.ValidRune(c) {
+			err = &Error{i, "unicode escape sequence is not a valid code point"}
+			return
+		}
+		return c, false, end + 1, nil
+
+	default:
+		err = &Error{i, "unknown escape sequence"}
+		return
+	}
+
+	return c, false, 2, nil
+}
+
+func isHex(b byte) bool {
+	return b >= '0' && b <= '9' || b|0x20 >= 'a' && b|0x20 <= 'f'
+}
+
+func hexValue(b byte) rune {
+	if b <= '9' {
+		return rune(b - '0')
+	}
+	return rune(b|0x20-'a') + 10
+}
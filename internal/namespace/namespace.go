@@ -5,20 +5,16 @@ package namespace
 
 import (
 	"strings"
+
+	"github.com/tsavola/dp/internal/literal"
 )
 
 func UnquoteImportPath(s string) (string, bool) {
 	if !(strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`)) {
 		panic(s)
 	}
-	s = s[1 : len(s)-1]
-	for i, c := range s {
-		if c == '\\' {
-			// Return the valid prefix.
-			return s[:i], false
-		}
-	}
-	return s, true
+	s, err := literal.Unquote(s)
+	return s, err == nil
 }
 
 func ImportPathNamespaces(path string) []string {
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+import (
+	"slices"
+
+	"github.com/tsavola/dp/source"
+)
+
+type Type struct {
+	Assigner  bool
+	Pointer   bool
+	Reference bool
+	Shared    bool
+	Item      *Type         // Non-nil if array.
+	Name      QualifiedName // Empty if array.
+}
+
+func (t Type) Equal(other Type) bool {
+	if t.Item != nil {
+		if other.Item == nil || !t.Item.Equal(*other.Item) {
+			return false
+		}
+	} else {
+		if other.Item != nil {
+			return false
+		}
+	}
+	return slices.Equal(t.Name, other.Name) && t.Shared == other.Shared && t.Reference == other.Reference && t.Pointer == other.Pointer && t.Assigner == other.Assigner
+}
+
+func (t Type) String() string {
+	var s string
+	if t.Item != nil {
+		s = "[" + t.Item.String() + "]"
+	} else {
+		s = t.Name.String()
+	}
+	if t.Shared {
+		s = "#" + s
+	}
+	if t.Reference {
+		s = "&" + s
+	}
+	if t.Pointer {
+		s = "*" + s
+	}
+	if t.Assigner {
+		s = "=" + s
+	}
+	return s
+}
+
+type TypeSpec struct {
+	At source.Position
+	Type
+	EndAt source.Position
+}
+
+func (TypeSpec) Node() string           { return "TypeSpec" }
+func (TypeSpec) typeListChild()         {}
+func (x TypeSpec) Pos() source.Position { return x.At }
+func (x TypeSpec) End() source.Position { return x.EndAt }
+func (x TypeSpec) Dump() string         { return "TypeSpec{" + x.Type.String() + "}" }
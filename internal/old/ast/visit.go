@@ -0,0 +1,286 @@
+// Copyright (c) 2023 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ast
+
+func VisitAssignListChild(x AssignListChild,
+	visitAssigner func(AssignerDereference),
+	visitCall func(Call),
+	visitCast func(Cast),
+	visitIndex func(Index),
+	visitSelector func(Selector),
+) {
+	switch x := x.(type) {
+	case AssignerDereference:
+		visitAssigner(x)
+	case Call:
+		visitCall(x)
+	case Cast:
+		visitCast(x)
+	case Index:
+		visitIndex(x)
+	case Selector:
+		visitSelector(x)
+	default:
+		panic("unknown assignment list child node type")
+	}
+}
+
+func VisitBlockChild(x BlockChild,
+	visitAssign func(Assign),
+	visitBadStmt func(BadStmt),
+	visitBlock func(Block),
+	visitBreak func(Break),
+	visitComment func(Comment),
+	visitContinue func(Continue),
+	visitExpression func(Expression),
+	visitFor func(For),
+	visitIf func(If),
+	visitImport func(Import),
+	visitReturn func(Return),
+	visitSwitch func(Switch),
+	visitVariableDecl func(VariableDecl),
+	visitVariableDef func(VariableDef),
+) {
+	switch x := x.(type) {
+	case Assign:
+		visitAssign(x)
+	case BadStmt:
+		visitBadStmt(x)
+	case Block:
+		visitBlock(x)
+	case Break:
+		visitBreak(x)
+	case Comment:
+		visitComment(x)
+	case Continue:
+		visitContinue(x)
+	case Expression:
+		visitExpression(x)
+	case For:
+		visitFor(x)
+	case If:
+		visitIf(x)
+	case Import:
+		visitImport(x)
+	case Return:
+		visitReturn(x)
+	case Switch:
+		visitSwitch(x)
+	case VariableDecl:
+		visitVariableDecl(x)
+	case VariableDef:
+		visitVariableDef(x)
+	default:
+		panic("unknown block child node type")
+	}
+}
+
+func VisitCaseListChild(x CaseListChild,
+	visitCase func(Case),
+	visitComment func(Comment),
+) {
+	switch x := x.(type) {
+	case Case:
+		visitCase(x)
+	case Comment:
+		visitComment(x)
+	default:
+		panic("unknown case list child node type")
+	}
+}
+
+func VisitElemListChild(x ElemListChild,
+	visitComment func(Comment),
+	visitExpression func(Expression),
+	visitKeyValue func(KeyValue),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Expression:
+		visitExpression(x)
+	case KeyValue:
+		visitKeyValue(x)
+	default:
+		panic("unknown element list child node type")
+	}
+}
+
+func VisitExpr(x ExprChild,
+	visitAddress func(Address),
+	visitBinary func(Binary),
+	visitBoolean func(Boolean),
+	visitCall func(Call),
+	visitCast func(Cast),
+	visitCharacter func(Character),
+	visitClone func(Clone),
+	visitComposite func(CompositeLit),
+	visitEmpty func(Empty),
+	visitFloat func(Float),
+	visitIndex func(Index),
+	visitInteger func(Integer),
+	visitNil func(Nil),
+	visitPointer func(PointerDereference),
+	visitSelector func(Selector),
+	visitString func(String),
+	visitUnary func(Unary),
+) {
+	switch x := x.(type) {
+	case Address:
+		visitAddress(x)
+	case Binary:
+		visitBinary(x)
+	case Boolean:
+		visitBoolean(x)
+	case Call:
+		visitCall(x)
+	case Cast:
+		visitCast(x)
+	case Character:
+		visitCharacter(x)
+	case Clone:
+		visitClone(x)
+	case CompositeLit:
+		visitComposite(x)
+	case Empty:
+		visitEmpty(x)
+	case Float:
+		visitFloat(x)
+	case Index:
+		visitIndex(x)
+	case Integer:
+		visitInteger(x)
+	case Nil:
+		visitNil(x)
+	case PointerDereference:
+		visitPointer(x)
+	case Selector:
+		visitSelector(x)
+	case String:
+		visitString(x)
+	case Unary:
+		visitUnary(x)
+	default:
+		panic("unknown expression child node type")
+	}
+}
+
+func VisitExprListChild(x ExprListChild,
+	visitAssigner func(AssignerDereference),
+	visitComment func(Comment),
+	visitExpression func(Expression),
+) {
+	switch x := x.(type) {
+	case AssignerDereference:
+		visitAssigner(x)
+	case Comment:
+		visitComment(x)
+	case Expression:
+		visitExpression(x)
+	default:
+		panic("unknown expression list child node type")
+	}
+}
+
+func VisitFieldListChild(x FieldListChild,
+	visitComment func(Comment),
+	visitField func(Field),
+	visitImport func(Import),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Field:
+		visitField(x)
+	case Import:
+		visitImport(x)
+	default:
+		panic("unknown field list child node type")
+	}
+}
+
+func VisitFileChild(x FileChild,
+	visitBadDecl func(BadDecl),
+	visitComment func(Comment),
+	visitConstant func(ConstantDef),
+	visitFunction func(FunctionDef),
+	visitImport func(Import),
+	visitImports func(Imports),
+	visitType func(TypeDef),
+) {
+	switch x := x.(type) {
+	case BadDecl:
+		visitBadDecl(x)
+	case Comment:
+		visitComment(x)
+	case ConstantDef:
+		visitConstant(x)
+	case FunctionDef:
+		visitFunction(x)
+	case Import:
+		visitImport(x)
+	case Imports:
+		visitImports(x)
+	case TypeDef:
+		visitType(x)
+	default:
+		panic("unknown file child node type")
+	}
+}
+
+func VisitIdentListChild(x IdentListChild,
+	visitComment func(Comment),
+	visitIdentifier func(Identifier),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Identifier:
+		visitIdentifier(x)
+	default:
+		panic("unknown identifier list child node type")
+	}
+}
+
+func VisitImportListChild(x ImportListChild,
+	visitComment func(Comment),
+	visitImport func(Import),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Import:
+		visitImport(x)
+	default:
+		panic("unknown import list child node type")
+	}
+}
+
+func VisitParamListChild(x ParamListChild,
+	visitComment func(Comment),
+	visitParameter func(Parameter),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case Parameter:
+		visitParameter(x)
+	default:
+		panic("unknown parameter list child node type")
+	}
+}
+
+func VisitTypeListChild(x TypeListChild,
+	visitComment func(Comment),
+	visitType func(TypeSpec),
+) {
+	switch x := x.(type) {
+	case Comment:
+		visitComment(x)
+	case TypeSpec:
+		visitType(x)
+	default:
+		panic("unknown type list child node type")
+	}
+}
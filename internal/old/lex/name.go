@@ -0,0 +1,51 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package lex
+
+import (
+	"unicode"
+
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/internal/position"
+)
+
+// checkName enforces naming rules.  Function and variable names consist of
+// lower-case letters and non-consecutive underscores.  Type names start with
+// an upper-case letter and don't contain underscores.
+func checkName(t token.Token) error {
+	var (
+		prev rune
+		msg  string
+	)
+
+	for i, c := range t.Source {
+		switch {
+		case c == '_':
+			switch {
+			case t.Kind == token.WordUpper:
+				msg = "underscore in type name"
+			case prev == '_':
+				msg = "consecutive underscores in name"
+			case i == len(t.Source)-1 && i > 0:
+				msg = "trailing underscore in name"
+			}
+
+		case t.Kind == token.WordUpper:
+
+		case unicode.IsDigit(c):
+			msg = "digit in name"
+
+		case !unicode.IsLower(c):
+			msg = "upper-case letter in name"
+		}
+
+		if msg != "" {
+			return position.NewError(position.After(t.At, t.Source[:i]), msg)
+		}
+
+		prev = c
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package lex
+
+import (
+	"io"
+
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/internal/position"
+	"github.com/tsavola/dp/source"
+)
+
+// Mode flags.
+type Mode uint
+
+const (
+	// LenientNames accepts function, variable and type names which don't
+	// follow the naming rules.
+	LenientNames Mode = 1 << iota
+
+	// AllErrors makes lexical errors non-fatal.  Invalid input is returned
+	// as Illegal tokens, and the errors are collected.  Names which don't
+	// follow the naming rules are returned as normal tokens.
+	AllErrors
+)
+
+// Scanner tokenizes input incrementally.
+type Scanner struct {
+	Mode Mode
+
+	s       scan
+	started bool
+	crlf    bool
+	errs    source.ErrorList
+	err     error
+}
+
+// NewScanner reads input from r as needed.  The first byte of the input is
+// located at pos.
+func NewScanner(pos source.Position, r io.Reader) *Scanner {
+	return &Scanner{s: scan{pos, &input{r: r, offset: pos.ByteOffset, keep: pos.ByteOffset}}}
+}
+
+// NewStringScanner tokenizes text which is located at pos.
+func NewStringScanner(pos source.Position, text string) *Scanner {
+	return &Scanner{s: scan{pos, &input{text: text, offset: pos.ByteOffset, keep: pos.ByteOffset}}}
+}
+
+// Next token.  io.EOF is returned at the end of input.  Errors are sticky.
+// Lexical errors are not returned in AllErrors mode.
+func (x *Scanner) Next() (t token.Token, err error) {
+	if x.err != nil {
+		return t, x.err
+	}
+
+	x.s.in.keep = x.s.ByteOffset
+
+	err = pan.Recover(func() {
+		x.s, t = x.tokenize()
+	})
+
+	if err == nil && x.Mode&LenientNames == 0 {
+		switch t.Kind {
+		case token.WordLower, token.WordUpper:
+			if err = checkName(t); err != nil && x.Mode&AllErrors != 0 {
+				x.errs = append(x.errs, err)
+				err = nil
+			}
+		}
+	}
+
+	if _, ok := err.(position.Error); ok && x.Mode&AllErrors != 0 {
+		err = pan.Recover(func() {
+			t = x.illegal(err)
+		})
+	}
+
+	if err != nil {
+		if e, ok := err.(spanError); ok {
+			err = e.err
+		}
+		x.err = err
+		return token.Token{}, err
+	}
+
+	return t, nil
+}
+
+// Errors which have been encountered in AllErrors mode.
+func (x *Scanner) Errors() source.ErrorList {
+	return x.errs
+}
+
+// CRLF reports if a \r\n line ending has been encountered.
+func (x *Scanner) CRLF() bool {
+	return x.crlf
+}
+
+func (x *Scanner) tokenize() (scan, token.Token) {
+	if !x.started {
+		x.started = true
+
+		x.s = skipBOM(x.s)
+		if x.s.hasPrefix("#!") {
+			return tokenizeShebang(x.s)
+		}
+	}
+
+	s := x.s
+
+	switch s.peek() {
+	case 0:
+		pan.Panic(io.EOF)
+
+	case invalid:
+		pan.Panic(decodeError(s.pos()))
+	}
+
+	s, t := tokenizeNext(s)
+
+	if t.Kind == token.Newline && t.Source != "\n" {
+		x.crlf = true
+	}
+
+	return s, t
+}
+
+// illegal records err and skips the invalid input.
+func (x *Scanner) illegal(err error) (t token.Token) {
+	var end scan
+	if e, ok := err.(spanError); ok {
+		end = e.end
+		err = e.err
+	} else {
+		end = skipIllegal(x.s)
+	}
+
+	x.errs = append(x.errs, err)
+	x.s, t = makeToken(token.Illegal, x.s, end)
+	return
+}
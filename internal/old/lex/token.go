@@ -0,0 +1,466 @@
+// Copyright (c) 2023 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package lex implements lexical analysis.
+package lex
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tsavola/dp/internal/literal"
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/source"
+)
+
+var keywords = map[string]token.Kind{
+	"auto":     token.Auto,
+	"break":    token.Break,
+	"case":     token.Case,
+	"clone":    token.Clone,
+	"continue": token.Continue,
+	"default":  token.Default,
+	"else":     token.Else,
+	"false":    token.False,
+	"for":      token.For,
+	"if":       token.If,
+	"import":   token.Import,
+	"nil":      token.Nil,
+	"range":    token.Range,
+	"return":   token.Return,
+	"switch":   token.Switch,
+	"true":     token.True,
+}
+
+const bom = "\uFEFF"
+
+type operator struct {
+	source string
+	kind   token.Kind
+}
+
+// operators indexed by first character.  Longer alternatives come first.
+var operators = [utf8.RuneSelf][]operator{
+	'+': {{"+=", token.PlusAssign}, {"+", token.Plus}},
+	'-': {{"-=", token.MinusAssign}, {"-", token.Minus}},
+	'*': {{"*=", token.AsteriskAssign}, {"*", token.Asterisk}},
+	'/': {{"/=", token.SlashAssign}, {"/", token.Slash}},
+	'%': {{"%=", token.PercentAssign}, {"%", token.Percent}},
+
+	'&': {{"&&", token.LogicalAnd}, {"&^=", token.AndNotAssign}, {"&^", token.AndNot}, {"&=", token.AmpersandAssign}, {"&", token.Ampersand}},
+	'|': {{"||", token.LogicalOr}, {"|=", token.PipeAssign}, {"|", token.Pipe}},
+	'^': {{"^=", token.CaretAssign}, {"^", token.Caret}},
+
+	'<': {{"<<=", token.ShiftLeftAssign}, {"<<", token.ShiftLeft}, {"<=", token.LessOrEqual}, {"<", token.Less}},
+	'>': {{">>=", token.ShiftRightAssign}, {">>", token.ShiftRight}, {">=", token.GreaterOrEqual}, {">", token.Greater}},
+
+	'=': {{"==", token.Equal}, {"=", token.Assign}},
+	'!': {{"!=", token.NotEqual}, {"!", token.Exclamation}},
+
+	',': {{",", token.Comma}},
+	'.': {{".", token.Period}},
+	';': {{";", token.Semicolon}},
+	':': {{":=", token.Define}, {"::", token.Colons}, {":", token.Colon}},
+	'#': {{"#", token.Hash}},
+
+	'(': {{"(", token.ParenLeft}},
+	'[': {{"[", token.BracketLeft}},
+	'{': {{"{", token.BraceLeft}},
+
+	')': {{")", token.ParenRight}},
+	']': {{"]", token.BracketRight}},
+	'}': {{"}", token.BraceRight}},
+}
+
+// File tokenizes the whole text which is located at pos.
+func File(pos source.Position, text string) ([]token.Token, error) {
+	return FileMode(pos, text, 0)
+}
+
+// FileMode is like File, but with Scanner mode flags.  In AllErrors mode
+// tokens are returned also when there are lexical errors; the error is a
+// source.ErrorList.
+func FileMode(pos source.Position, text string, mode Mode) ([]token.Token, error) {
+	var (
+		s      = NewStringScanner(pos, text)
+		tokens []token.Token
+	)
+
+	s.Mode = mode
+
+	for {
+		t, err := s.Next()
+		if err != nil {
+			if err == io.EOF {
+				return tokens, s.Errors().Err()
+			}
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+}
+
+// skipBOM skips byte order mark.  It doesn't occupy a column.
+func skipBOM(s scan) scan {
+	if s.hasPrefix(bom) {
+		s.ByteOffset += len(bom)
+	}
+	return s
+}
+
+// tokenizeShebang consumes an interpreter directive line as a comment.
+func tokenizeShebang(s scan) (scan, token.Token) {
+	end, ok := scanComment(s)
+	if !ok {
+		pan.Panic(tokenError(s))
+	}
+	return makeToken(token.Comment, s, end)
+}
+
+// tokenizeNext dispatches on the first rune, which must be valid.
+func tokenizeNext(s scan) (scan, token.Token) {
+	start := s
+
+	switch c := s.peek(); {
+	case c == '\n':
+		s.advance()
+		return makeToken(token.Newline, start, s)
+
+	case c == '\r' && s.hasPrefix("\r\n"):
+		s.advanceASCII(1)
+		s.advance()
+		return makeToken(token.Newline, start, s)
+
+	case c == '/' && s.hasPrefix("/*"):
+		return tokenizeBlockComment(s)
+
+	case c == '/' && s.hasPrefix("//"):
+		if end, ok := scanComment(s); ok {
+			kind := token.Comment
+			if s.hasPrefix("///") && !s.hasPrefix("////") {
+				kind = token.DocComment
+			}
+			return makeToken(kind, start, end)
+		}
+		fallthrough // Slash operator if comment is not encoded correctly.
+
+	case c < utf8.RuneSelf && operators[c] != nil:
+		for _, op := range operators[c] {
+			if s.hasPrefix(op.source) {
+				s.advanceASCII(len(op.source))
+				return makeToken(op.kind, start, s)
+			}
+		}
+
+	case c == '\'':
+		return tokenizeQuoted(s, token.Character, c)
+
+	case c == '"' || c == '`':
+		return tokenizeQuoted(s, token.String, c)
+
+	case wordStartRune(c):
+		return tokenizeWord(s)
+
+	case isDigit(c, 10):
+		return tokenizeNumber(s)
+
+	case unicode.IsSpace(c):
+		return tokenizeSpace(s)
+	}
+
+	panic(pan.Wrap(tokenError(s)))
+}
+
+func tokenizeSpace(s scan) (scan, token.Token) {
+	start := s
+
+	for {
+		if c := s.peekWithin(start); c == '\n' || !unicode.IsSpace(c) || (c == '\r' && s.hasPrefix("\r\n")) {
+			return makeToken(token.Space, start, s)
+		}
+		s.advance()
+	}
+}
+
+func scanComment(s scan) (scan, bool) {
+	for {
+		switch s.peek() {
+		case '\n', 0:
+			return s, true
+		case '\r':
+			if s.hasPrefix("\r\n") {
+				return s, true
+			}
+		case invalid:
+			return s, false
+		}
+		s.advance()
+	}
+}
+
+// tokenizeBlockComment consumes a block comment.  Block comments nest.
+func tokenizeBlockComment(s scan) (scan, token.Token) {
+	start := s
+	s.advanceASCII(2)
+
+	for depth := 1; depth > 0; {
+		switch {
+		case s.hasPrefix("*/"):
+			s.advanceASCII(2)
+			depth--
+
+		case s.hasPrefix("/*"):
+			s.advanceASCII(2)
+			depth++
+
+		default:
+			if s.peekWithin(start) == 0 {
+				pan.Panic(tokenError(start))
+			}
+			s.advance()
+		}
+	}
+
+	return makeToken(token.BlockComment, start, s)
+}
+
+func tokenizeWord(s scan) (scan, token.Token) {
+	start := s
+	upper := unicode.IsUpper(s.peek())
+
+	for {
+		s.advance()
+
+		if !wordRune(s.peekWithin(start)) {
+			break
+		}
+	}
+
+	if upper {
+		return makeToken(token.WordUpper, start, s)
+	}
+
+	if k, ok := keywords[start.until(s.ByteOffset)]; ok {
+		return makeToken(k, start, s)
+	}
+
+	return makeToken(token.WordLower, start, s)
+}
+
+func tokenizeNumber(s scan) (scan, token.Token) {
+	start := s
+	kind := token.Integer
+	base := 10
+
+	if s.peek() == '0' {
+		prefix := s
+		prefix.advance()
+
+		switch unicode.ToLower(prefix.peekWithin(start)) {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+
+		if base != 10 {
+			s = prefix
+			s.advance()
+		}
+	}
+
+	digits := scanDigits(&s, start, base, base != 10)
+
+	if base == 10 || base == 16 {
+		if s.peek() == '.' {
+			fraction := s
+			fraction.advance()
+
+			if isDigit(fraction.peekWithin(start), base) {
+				s = fraction
+				kind = token.Float
+				digits += scanDigits(&s, start, base, false)
+			}
+		}
+
+		if c := unicode.ToLower(s.peekWithin(start)); (base == 10 && c == 'e') || (base == 16 && c == 'p') {
+			s.advance()
+			kind = token.Float
+
+			if c := s.peekWithin(start); c == '+' || c == '-' {
+				s.advance()
+			}
+
+			if scanDigits(&s, start, 10, false) == 0 {
+				pan.Panic(numberError(s, "exponent has no digits"))
+			}
+		} else if base == 16 && kind == token.Float {
+			pan.Panic(numberError(s, "hexadecimal mantissa requires p exponent"))
+		}
+	}
+
+	if c := s.peekWithin(start); isDigit(c, 10) {
+		pan.Panic(numberError(s, "invalid digit %q in %s literal", c, baseNames[base]))
+	} else if wordRune(c) {
+		pan.Panic(numberError(s, "invalid character %q in %s literal", c, baseNames[base]))
+	}
+
+	if digits == 0 {
+		pan.Panic(numberError(s, "%s literal has no digits", baseNames[base]))
+	}
+
+	return makeToken(kind, start, s)
+}
+
+var baseNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	10: "decimal",
+	16: "hexadecimal",
+}
+
+// scanDigits returns the number of digits.  Leading separator is permitted
+// after base prefix.
+func scanDigits(s *scan, start scan, base int, prefixed bool) (digits int) {
+	separator := false
+
+	for {
+		c := s.peekWithin(start)
+
+		switch {
+		case c == '_':
+			if separator || (digits == 0 && !prefixed) {
+				pan.Panic(numberError(*s, "'_' must separate successive digits"))
+			}
+			separator = true
+
+		case isDigit(c, base):
+			separator = false
+			digits++
+
+		default:
+			if separator {
+				pan.Panic(numberError(*s, "'_' must separate successive digits"))
+			}
+			return
+		}
+
+		s.advance()
+	}
+}
+
+func tokenizeQuoted(s scan, k token.Kind, quote rune) (scan, token.Token) {
+	start := s
+	s.advance()
+
+	for {
+		c := s.peekWithin(start)
+		if c == 0 {
+			pan.Panic(tokenError(start))
+		}
+
+		s.advance()
+
+		switch c {
+		case '\\':
+			if quote != '`' {
+				if s.peekWithin(start) == 0 {
+					pan.Panic(tokenError(start))
+				}
+				s.advance()
+			}
+
+		case quote:
+			checkQuoted(start, s, k)
+			return makeToken(k, start, s)
+		}
+	}
+}
+
+// checkQuoted validates escape sequences and character count.
+func checkQuoted(start, end scan, k token.Kind) {
+	source := start.until(end.ByteOffset)
+
+	var err error
+	if k == token.Character {
+		_, err = literal.UnquoteChar(source)
+	} else {
+		_, err = literal.Unquote(source)
+	}
+
+	if e, ok := err.(*literal.Error); ok {
+		pan.Panic(spanError{literalError(start, source, e), end})
+	}
+}
+
+// skipIllegal returns the end of the invalid input which starts at s.  Invalid
+// words and numbers are skipped as a whole.  Otherwise the span extends over
+// runes which can't start a token.
+func skipIllegal(s scan) scan {
+	var (
+		c      = s.peek()
+		number = isDigit(c, 10)
+		word   = wordRune(c)
+	)
+
+	for first := true; ; first = false {
+		switch c = s.peek(); {
+		case c == 0:
+			return s
+
+		case c == invalid:
+			s.skipByte()
+
+		case first, word && wordRune(c), number && c == '.', !word && !tokenStartRune(c):
+			s.advance()
+
+		default:
+			return s
+		}
+	}
+}
+
+func makeToken(k token.Kind, start, end scan) (scan, token.Token) {
+	source := start.until(end.ByteOffset)
+	if source == "" {
+		pan.Panic(tokenError(start))
+	}
+
+	return end, token.Token{start.pos(), k, source}
+}
+
+func isDigit(r rune, base int) bool {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r-'0') < base
+	case base == 16:
+		r |= 0x20 // Lower case.
+		return r >= 'a' && r <= 'f'
+	default:
+		return false
+	}
+}
+
+func tokenStartRune(c rune) bool {
+	switch {
+	case c < utf8.RuneSelf && operators[c] != nil:
+		return true
+	case c == '\'' || c == '"' || c == '`':
+		return true
+	default:
+		return wordStartRune(c) || isDigit(c, 10) || unicode.IsSpace(c)
+	}
+}
+
+func wordStartRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func wordRune(r rune) bool {
+	return wordStartRune(r) || unicode.IsDigit(r)
+}
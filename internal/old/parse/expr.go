@@ -0,0 +1,338 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/internal/pan"
+)
+
+// parseAnyExpr parses operands joined by infix operators.  Operators are
+// left-associative, and operators of different precedence must not be mixed
+// without parentheses, so the loop needs to remember only the first operator.
+func parseAnyExpr(s scan, multiline bool) (scan, ast.ExprChild) {
+	if multiline {
+		skipNewlines(&s)
+	}
+
+	s, left := parseOperand(s)
+
+	var first ast.BinaryOp
+
+	for {
+		if multiline {
+			skipNewlines(&s)
+		}
+
+		op, ok := infixOperator(s.peek().Kind)
+		if !ok {
+			return s, left
+		}
+		s.skip(token.Kind(op))
+
+		if first != 0 && op.Precedence() != first.Precedence() {
+			pan.Panic(newError(s.pos(), "operators have different precedence"))
+		}
+		if first == 0 {
+			first = op
+		}
+
+		if multiline {
+			skipNewlines(&s)
+		}
+
+		var right ast.ExprChild
+		s, right = parseOperand(s)
+		left = ast.Binary{left, op, right, s.last}
+	}
+}
+
+func skipNewlines(s *scan) {
+	for s.skip(token.Newline) {
+	}
+}
+
+// parseOperand parses an expression which doesn't contain infix operators
+// outside of parentheses.
+func parseOperand(s scan) (scan, ast.ExprChild) {
+	t := s.peek()
+
+	switch t.Kind {
+	case token.Ampersand:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Address{t.Pos(), expr, s.last}
+
+	case token.Asterisk:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.PointerDereference{t.Pos(), expr, s.last}
+
+	case token.BraceLeft:
+		s.skip(t.Kind)
+		s.take(token.BraceRight, "closing brace of empty value")
+		return s, ast.Empty{t.Pos(), s.last}
+
+	case token.Character:
+		s.skip(t.Kind)
+		return s, ast.Character{t.Pos(), t.Source}
+
+	case token.Clone:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Clone{t.Pos(), expr, s.last}
+
+	case token.False, token.True:
+		s.skip(t.Kind)
+		return s, ast.Boolean{t.Pos(), t.Source}
+
+	case token.Float:
+		s.skip(t.Kind)
+		return s, ast.Float{t.Pos(), t.Source}
+
+	case token.Integer:
+		s.skip(t.Kind)
+		return s, ast.Integer{t.Pos(), t.Source}
+
+	case token.Nil:
+		s.skip(t.Kind)
+		return s, ast.Nil{t.Pos(), s.last}
+
+	case token.BracketLeft, token.Colons:
+		return parseCompositeLit(s)
+
+	case token.ParenLeft:
+		s.skip(t.Kind)
+		s, expr := parseNestedExpr(s)
+		s.take(token.ParenRight, "closing parenthesis")
+		return s, expr
+
+	case token.String:
+		s.skip(t.Kind)
+		return s, ast.String{t.Pos(), t.Source}
+
+	case token.WordLower:
+		s, name := parseSelectorOnly(s)
+		switch s.peek().Kind {
+		case token.BracketLeft:
+			return parseIndex(s, name)
+		case token.ParenLeft:
+			return parseCall(s, name)
+		}
+		return s, name
+
+	case token.WordUpper:
+		if s.lookahead(1).Kind == token.ParenLeft {
+			return parseCast(s)
+		}
+		return parseCompositeLit(s)
+	}
+
+	if op, ok := prefixOperator(t.Kind); ok {
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Unary{t.Pos(), op, expr, s.last}
+	}
+
+	unexpected(s, "expression")
+	return s, nil
+}
+
+// parseNestedExpr parses an expression enclosed in brackets, where composite
+// literals are always allowed.
+func parseNestedExpr(s scan) (scan, ast.ExprChild) {
+	header := s.header
+	s.header = false
+	s, expr := parseAnyExpr(s, true)
+	s.header = header
+	return s, expr
+}
+
+func parseExprList(s scan) (scan, []ast.ExprListChild) {
+	if s.skip(token.ParenLeft) {
+		header := s.header
+		s.header = false
+		s, list := parseListUntil(s, skipper(token.ParenRight), parseExprListChild)
+		s.header = header
+		return s, list
+	} else {
+		return parseNakedList(s, 0, parseExprListChildNaked)
+	}
+}
+
+// parseExprListChild parses an item of a parenthesized expression list.
+func parseExprListChild(s scan) (scan, ast.ExprListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+
+	case isComment(k):
+		return parseComment(s)
+
+	case k == token.Newline:
+		return parseNewline(s), nil
+	}
+
+	return parseExpression(s)
+}
+
+// parseExprListChildNaked parses an item of an expression list which ends at
+// end of statement.
+func parseExprListChildNaked(s scan) (scan, ast.ExprListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+
+	return parseExpression(s)
+}
+
+// parseExpression parses an expression list item.  It must be followed by a
+// comma or end of list.
+func parseExpression(s scan) (scan, ast.ExprListChild) {
+	s, node, ok := parseAssignerDereference(s)
+	if ok {
+		return s, node
+	}
+
+	s, expr := parseAnyExpr(s, false)
+
+	if !s.skip(token.Comma) {
+		switch k := s.peek().Kind; {
+		case k == token.BraceRight, k == token.Colon, k == token.Newline, k == token.ParenRight, k == token.Semicolon, isComment(k):
+		default:
+			unexpected(s, "comma or end of expression list")
+		}
+	}
+
+	return s, ast.Expression{expr}
+}
+
+// parseAssignerDereference parses variable name in parentheses.  The scan
+// state is not advanced if the tokens don't match.
+func parseAssignerDereference(s scan) (scan, ast.AssignerDereference, bool) {
+	if s.peek().Kind != token.ParenLeft || s.lookahead(2).Kind != token.ParenRight {
+		return s, ast.AssignerDereference{}, false
+	}
+
+	t := s.lookahead(1)
+	if t.Kind != token.WordLower {
+		return s, ast.AssignerDereference{}, false
+	}
+
+	pos := s.pos()
+	s.skip(token.ParenLeft)
+	s.skip(token.WordLower)
+	s.skip(token.ParenRight)
+
+	return s, ast.AssignerDereference{pos, t.Source, s.last}, true
+}
+
+func parseCall(s scan, name ast.Selector) (scan, ast.Call) {
+	s.take(token.ParenLeft, "opening parenthesis of call arguments")
+
+	header := s.header
+	s.header = false
+	s, args := parseListUntil(s, skipper(token.ParenRight), parseExprListChild)
+	s.header = header
+
+	return s, ast.Call{name, args, s.last}
+}
+
+func parseCast(s scan) (scan, ast.Cast) {
+	t := s.take(token.WordUpper, "type name")
+
+	s.take(token.ParenLeft, "opening parenthesis of type conversion")
+	s, expr := parseNestedExpr(s)
+	s.take(token.ParenRight, "closing parenthesis of type conversion")
+
+	return s, ast.Cast{t.Pos(), t.Source, expr, s.last}
+}
+
+// parseCompositeLit parses array or struct literal.  In if, for and switch
+// headers, struct literals must be enclosed in parentheses so that the type
+// name is not mistaken for the last operand before the body.
+func parseCompositeLit(s scan) (scan, ast.CompositeLit) {
+	pos := s.pos()
+	s, t := parseType(s)
+
+	if s.header && t.Item == nil {
+		pan.Panic(newError(pos, "composite literal in statement header must be parenthesized"))
+	}
+
+	s.take(token.BraceLeft, "opening brace of composite literal")
+
+	header := s.header
+	s.header = false
+	s, elems := parseListUntil(s, skipper(token.BraceRight), parseElemListChild)
+	s.header = header
+
+	return s, ast.CompositeLit{pos, t, elems, s.last}
+}
+
+func parseElemListChild(s scan) (scan, ast.ElemListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+
+	case isComment(k):
+		return parseComment(s)
+
+	case k == token.Newline:
+		return parseNewline(s), nil
+	}
+
+	return parseElement(s)
+}
+
+// parseElement parses a composite literal element with optional field name.
+// It must be followed by a comma or end of list.
+func parseElement(s scan) (scan, ast.ElemListChild) {
+	pos := s.pos()
+
+	var key string
+	if s.peek().Kind == token.WordLower && s.lookahead(1).Kind == token.Colon {
+		key = s.take(token.WordLower, "field name").Source
+		s.skip(token.Colon)
+	}
+
+	s, value := parseAnyExpr(s, false)
+
+	if !s.skip(token.Comma) {
+		switch k := s.peek().Kind; {
+		case k == token.BraceRight, k == token.Newline, isComment(k):
+		default:
+			unexpected(s, "comma or closing brace of composite literal")
+		}
+	}
+
+	if key == "" {
+		return s, ast.Expression{value}
+	}
+	return s, ast.KeyValue{pos, key, value}
+}
+
+func parseIndex(s scan, name ast.Selector) (scan, ast.Index) {
+	s.take(token.BracketLeft, "opening bracket of index")
+	s, index := parseNestedExpr(s)
+	s.take(token.BracketRight, "closing bracket of index")
+	return s, ast.Index{name, index, s.last}
+}
+
+func parseSelectorOnly(s scan) (scan, ast.Selector) {
+	pos := s.pos()
+	name := s.take(token.WordLower, "variable name")
+
+	var names []string
+
+	for {
+		names = append(names, name.Source)
+
+		if !s.skip(token.Period) {
+			return s, ast.Selector{pos, names, s.last}
+		}
+
+		name = s.take(token.WordLower, "field name")
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package parse implements parser.
+package parse
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/field"
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/source"
+)
+
+// Mode flags.
+type Mode uint
+
+const (
+	// AllErrors makes syntax errors non-fatal.  Declarations and statements
+	// which can't be parsed are represented by ast.BadDecl and ast.BadStmt
+	// nodes.  The nodes are returned together with a source.ErrorList.
+	AllErrors Mode = 1 << iota
+)
+
+func File(tokens []token.Token) ([]ast.FileChild, error) {
+	return FileMode(tokens, 0)
+}
+
+// FileMode is like File, but with mode flags.
+func FileMode(tokens []token.Token, mode Mode) ([]ast.FileChild, error) {
+	return parseFile(&tokenBuffer{buf: tokens, mode: mode}, nil)
+}
+
+// Stream reads tokens from src while parsing.  Tokenization error takes
+// precedence over syntax error.
+func Stream(src TokenSource) ([]ast.FileChild, error) {
+	return StreamMode(src, 0)
+}
+
+// StreamMode is like Stream, but with mode flags.  In AllErrors mode, the
+// errors listed by a lex.Scanner (in its AllErrors mode) are included in the
+// returned error list.
+func StreamMode(src TokenSource, mode Mode) ([]ast.FileChild, error) {
+	return parseFile(&tokenBuffer{source: src, mode: mode}, src)
+}
+
+func parseFile(ts *tokenBuffer, src TokenSource) ([]ast.FileChild, error) {
+	var (
+		end   scan
+		nodes []ast.FileChild
+	)
+
+	err := pan.Recover(func() {
+		end, nodes = parseTokens(ts)
+	})
+	if ts.err != nil {
+		return nil, ts.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	labelErrs := checkLabels(nodes)
+	if ts.mode&AllErrors == 0 && len(labelErrs) > 0 {
+		return nil, labelErrs[0]
+	}
+
+	errs := slices.Concat(end.errs, labelErrs)
+	if lister, ok := src.(errorLister); ok {
+		errs = slices.Concat(lister.Errors(), errs)
+	}
+	slices.SortStableFunc(errs, compareErrorPositions)
+
+	return nodes, errs.Err()
+}
+
+func parseTokens(ts *tokenBuffer) (scan, []ast.FileChild) {
+	return parseListRecover(scan{ts, 0, source.Position{}, nil, false}, peekEOF, true, badDecl, parseFileChild)
+}
+
+func parseFileChild(s scan) (scan, ast.FileChild) {
+	switch t := s.peek(); {
+	case isComment(t.Kind):
+		return parseComment(s)
+
+	case t.Kind == token.Import:
+		return parseImports(s)
+
+	case t.Kind == token.Newline:
+		return parseNewline(s), nil
+
+	case t.Kind == token.Semicolon:
+		return parseSemicolon(s), nil
+
+	case t.Kind == token.ParenLeft:
+		return parseFunctionDef(s)
+
+	case t.Kind == token.WordUpper:
+		return parseTypeDef(s)
+
+	case t.Kind == token.WordLower:
+		next := 1
+		if t.Source == "pub" {
+			switch s.lookahead(1).Kind {
+			case token.WordUpper:
+				return parseTypeDef(s)
+			case token.WordLower:
+				next = 2
+			}
+		}
+
+		if s.lookahead(next).Kind == token.Assign {
+			return parseConstantDef(s)
+		}
+		return parseFunctionDef(s)
+	}
+
+	unexpected(s, "declaration")
+	return s, nil
+}
+
+func parseConstantDef(s scan) (scan, ast.FileChild) {
+	var public bool
+	var name token.Token
+
+	t := s.take(token.WordLower, "pub keyword or constant name")
+	if t.Source == "pub" {
+		public = true
+		name = s.take(token.WordLower, "constant name")
+	} else {
+		name = t
+	}
+
+	s.take(token.Assign, "assignment operator")
+	s, value := parseAnyExpr(s, false)
+
+	return s, ast.ConstantDef{t.Pos(), public, name.Source, value, s.last}
+}
+
+func parseFieldAccess(s scan) (scan, field.Access) {
+	if t, ok := s.skim(token.WordLower); ok {
+		switch t.Source {
+		case "visible":
+			return s, field.AccessVisible
+		case "mutable":
+			return s, field.AccessMutable
+		case "assignable":
+			return s, field.AccessAssignable
+		}
+		pan.Panic(newError(t.Pos(), "expected visible, mutable or assignable keyword, found "+describe(t)))
+	}
+	return s, field.AccessHidden
+}
+
+func parseField(s scan) (scan, ast.Field) {
+	name := s.take(token.WordLower, "field name")
+	s, spec := parseTypeSpec(s)
+	s, access := parseFieldAccess(s)
+	return s, ast.Field{name.Pos(), name.Source, spec, access, s.last}
+}
+
+func parseFieldListChild(s scan) (scan, ast.FieldListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Import:
+		return parseImport(s, true)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseField(s)
+	}
+}
+
+func parseFunctionDef(s scan) (scan, ast.FileChild) {
+	pos := s.pos()
+
+	var (
+		public bool
+		rName  string
+		rType  *ast.TypeSpec
+		name   string
+	)
+
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "pub" {
+		s.skip(token.WordLower)
+		public = true
+	}
+
+	if s.skip(token.ParenLeft) {
+		rName = s.take(token.WordLower, "receiver name").Source
+
+		var t ast.TypeSpec
+		s, t = parseTypeSpec(s)
+		rType = &t
+
+		s.take(token.ParenRight, "closing paren of receiver")
+
+		if t, ok := s.skim(token.WordLower); ok {
+			name = t.Source
+		}
+	} else {
+		name = s.take(token.WordLower, "function name").Source
+	}
+
+	s.take(token.ParenLeft, "parameter list")
+	s, params := parseListUntil(s, skipper(token.ParenRight), parseParamListChild)
+	paramsEnd := s.last
+
+	params = fillInFunctionParamTypes(params)
+
+	var results []ast.TypeListChild
+	if s.skip(token.ParenLeft) {
+		s, results = parseListUntil(s, skipper(token.ParenRight), parseTypeListChild)
+	} else {
+		s, results = parseNakedList(s, token.BraceLeft, parseTypeListChildNaked)
+	}
+
+	bodyAt := s.take(token.BraceLeft, "opening brace of function body").Pos()
+	s, body := parseStatements(s)
+
+	return s, ast.FunctionDef{pos, public, rName, rType, name, params, paramsEnd, results, bodyAt, body, s.last}
+}
+
+func fillInFunctionParamTypes(nodes []ast.ParamListChild) []ast.ParamListChild {
+	var latest ast.TypeSpec
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		ast.VisitParamListChild(nodes[i],
+			func(ast.Comment) {},
+			func(node ast.Parameter) {
+				if typeSpecified(node.Type) {
+					latest = node.Type
+				} else {
+					if !typeSpecified(latest) {
+						pan.Panic(newError(node.EndAt, "function parameter type expected"))
+					}
+					node.Type = latest
+					nodes[i] = node
+				}
+			},
+		)
+	}
+
+	return nodes
+}
+
+func parseImport(s scan, requireKeyword bool) (scan, ast.Import) {
+	pos := s.pos()
+
+	if !s.skip(token.Import) && requireKeyword {
+		unexpected(s, "import keyword")
+	}
+
+	var path string
+	if t, ok := s.skim(token.String); ok {
+		path = t.Source
+		if !strings.HasPrefix(path, `"`) {
+			pan.Panic(newError(t.Pos(), "import path: opening quote expected"))
+		}
+	}
+
+	var names []ast.IdentListChild
+	if s.skip(token.ParenLeft) {
+		s, names = parseListUntil(s, skipper(token.ParenRight), parseIdentListChild)
+	} else {
+		s, names = parseNakedList(s, 0, parseIdentListChildNaked)
+	}
+
+	if !requireKeyword && path == "" && len(names) == 0 {
+		unexpected(s, "import path or identifier list")
+	}
+
+	return s, ast.Import{pos, path, names, s.last}
+}
+
+func parseImportListChild(s scan) (scan, ast.ImportListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseImport(s, false)
+	}
+}
+
+func parseImportPath(s scan) (scan, ast.ImportListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	}
+
+	path := s.take(token.String, "import path")
+	return s, ast.Import{path.Pos(), path.Source, nil, s.last}
+}
+
+func parseImportPathNaked(s scan) (scan, ast.ImportListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+
+	path := s.take(token.String, "import path")
+	return s, ast.Import{path.Pos(), path.Source, nil, s.last}
+}
+
+func parseImports(s scan) (scan, ast.FileChild) {
+	t := s.take(token.Import, "import keyword")
+
+	var imports []ast.ImportListChild
+
+	switch {
+	case s.peek().Kind == token.String:
+		s, imports = parseNakedList(s, 0, parseImportPathNaked)
+
+	case s.skip(token.ParenLeft):
+		s, imports = parseListUntil(s, skipper(token.ParenRight), parseImportPath)
+
+	default:
+		s.take(token.BraceLeft, "import path or opening brace")
+		s, imports = parseListUntil(s, skipper(token.BraceRight), parseImportListChild)
+	}
+
+	return s, ast.Imports{t.Pos(), imports, s.last}
+}
+
+func parseParam(s scan) (scan, ast.Parameter) {
+	name := s.take(token.WordLower, "parameter name")
+
+	// Missing type is filled in by parseFunctionDef().
+	var spec ast.TypeSpec
+	if !s.skip(token.Comma) {
+		s, spec = parseTypeSpec(s)
+		s.skim(token.Comma)
+	}
+
+	return s, ast.Parameter{name.Pos(), name.Source, spec, s.last}
+}
+
+func parseParamListChild(s scan) (scan, ast.ParamListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseParam(s)
+	}
+}
+
+func parseTypeDef(s scan) (scan, ast.FileChild) {
+	pos := s.pos()
+
+	var public bool
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "pub" {
+		public = true
+		s.skip(token.WordLower)
+	}
+
+	name := s.take(token.WordUpper, "type name")
+
+	s, access := parseFieldAccess(s)
+
+	s.take(token.BraceLeft, "opening brace of type definition")
+	s, body := parseListUntil(s, skipper(token.BraceRight), parseFieldListChild)
+
+	body = fillInTypeFieldAccess(body, access)
+
+	return s, ast.TypeDef{pos, public, name.Source, body, s.last}
+}
+
+func fillInTypeFieldAccess(nodes []ast.FieldListChild, fallback field.Access) []ast.FieldListChild {
+	if fallback != 0 {
+		for i, node := range nodes {
+			ast.VisitFieldListChild(node,
+				func(ast.Comment) {},
+				func(node ast.Field) {
+					if node.Access == 0 {
+						node.Access = fallback
+						nodes[i] = node
+					}
+				},
+				func(ast.Import) {},
+			)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"slices"
+
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/source"
+)
+
+// checkLabels verifies that labeled break and continue statements refer to
+// enclosing loops, and that nested loops don't reuse labels.
+func checkLabels(nodes []ast.FileChild) (errs source.ErrorList) {
+	for _, node := range nodes {
+		if def, ok := node.(ast.FunctionDef); ok {
+			checkBlockLabels(&errs, def.Body, nil)
+		}
+	}
+	return
+}
+
+// checkBlockLabels is called with the labels of enclosing loops.
+func checkBlockLabels(errs *source.ErrorList, nodes []ast.BlockChild, labels []string) {
+	checkJump := func(at source.Position, keyword, label string) {
+		if label != "" && !slices.Contains(labels, label) {
+			*errs = append(*errs, newError(at, keyword+" label "+label+" doesn't refer to an enclosing loop"))
+		}
+	}
+
+	for _, node := range nodes {
+		ast.VisitBlockChild(node,
+			func(ast.Assign) {},
+			func(ast.BadStmt) {},
+			func(node ast.Block) { checkBlockLabels(errs, node.Body, labels) },
+			func(node ast.Break) { checkJump(node.At, "break", node.Label) },
+			func(ast.Comment) {},
+			func(node ast.Continue) { checkJump(node.At, "continue", node.Label) },
+			func(ast.Expression) {},
+
+			func(node ast.For) {
+				inner := labels
+				if node.Label != "" {
+					if slices.Contains(labels, node.Label) {
+						*errs = append(*errs, newError(node.At, "label "+node.Label+" is already used by an enclosing loop"))
+					}
+					inner = append(labels[:len(labels):len(labels)], node.Label)
+				}
+				checkBlockLabels(errs, node.Body, inner)
+			},
+
+			func(node ast.If) {
+				checkBlockLabels(errs, node.Then, labels)
+				checkBlockLabels(errs, node.Else, labels)
+			},
+
+			func(ast.Import) {},
+			func(ast.Return) {},
+
+			func(node ast.Switch) {
+				for _, c := range node.Cases {
+					if c, ok := c.(ast.Case); ok {
+						checkBlockLabels(errs, c.Body, labels)
+					}
+				}
+			},
+
+			func(ast.VariableDecl) {},
+			func(ast.VariableDef) {},
+		)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/token"
+)
+
+// parseQualifiedName says what was expected if the name is missing.
+func parseQualifiedName(s scan, what string) (scan, ast.QualifiedName) {
+	var name ast.QualifiedName
+
+	if s.skip(token.Colons) {
+		name = append(name, "")
+	}
+
+	for {
+		t, ok := s.skim(token.WordLower)
+		if !ok {
+			t = s.take(token.WordUpper, what)
+		}
+		name = append(name, t.Source)
+
+		if !s.skip(token.Colons) {
+			return s, name
+		}
+	}
+}
+
+func parseIdentifier(s scan) (scan, ast.Identifier) {
+	pos := s.pos()
+	s, name := parseQualifiedName(s, "name")
+	return s, ast.Identifier{pos, name, s.last}
+}
+
+func parseIdentListChild(s scan) (scan, ast.IdentListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseIdentifier(s)
+	}
+}
+
+func parseIdentListChildNaked(s scan) (scan, ast.IdentListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+	return parseIdentifier(s)
+}
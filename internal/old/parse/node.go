@@ -0,0 +1,42 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/token"
+)
+
+func isComment(k token.Kind) bool {
+	switch k {
+	case token.BlockComment, token.Comment, token.DocComment:
+		return true
+	}
+	return false
+}
+
+func parseComma(s scan) scan {
+	s.take(token.Comma, "comma")
+	return s
+}
+
+func parseComment(s scan) (scan, ast.Comment) {
+	kind := s.peek().Kind
+	if !isComment(kind) {
+		kind = token.Comment
+	}
+
+	t := s.take(kind, "comment")
+	return s, ast.Comment{t.Pos(), t.Source}
+}
+
+func parseNewline(s scan) scan {
+	s.take(token.Newline, "end of line")
+	return s
+}
+
+func parseSemicolon(s scan) scan {
+	s.take(token.Semicolon, "semicolon")
+	return s
+}
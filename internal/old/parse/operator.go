@@ -0,0 +1,71 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/token"
+)
+
+func prefixOperator(k token.Kind) (ast.UnaryOp, bool) {
+	switch k {
+	case token.Plus, token.Minus, token.Caret, token.Exclamation:
+		return ast.UnaryOp(k), true
+
+	default:
+		return 0, false
+	}
+}
+
+func infixOperator(k token.Kind) (ast.BinaryOp, bool) {
+	switch k {
+	case token.Plus, token.Minus, token.Asterisk, token.Slash, token.Percent,
+		token.LogicalAnd, token.LogicalOr,
+		token.AndNot, token.Ampersand, token.Pipe, token.Caret,
+		token.ShiftLeft, token.ShiftRight,
+		token.Equal, token.NotEqual, token.LessOrEqual, token.GreaterOrEqual, token.Less, token.Greater:
+		return ast.BinaryOp(k), true
+
+	default:
+		return 0, false
+	}
+}
+
+// assignOperator returns the binary operator of compound assignment, or zero
+// for plain assignment.
+func assignOperator(k token.Kind) (ast.BinaryOp, bool) {
+	switch k {
+	case token.Assign:
+		return 0, true
+	case token.PlusAssign:
+		return ast.OpAdd, true
+	case token.MinusAssign:
+		return ast.OpSubtract, true
+	case token.AsteriskAssign:
+		return ast.OpMultiply, true
+	case token.SlashAssign:
+		return ast.OpDivide, true
+	case token.PercentAssign:
+		return ast.OpRemainder, true
+	case token.AndNotAssign:
+		return ast.OpAndNot, true
+	case token.AmpersandAssign:
+		return ast.OpAnd, true
+	case token.PipeAssign:
+		return ast.OpOr, true
+	case token.CaretAssign:
+		return ast.OpExclusiveOr, true
+	case token.ShiftLeftAssign:
+		return ast.OpShiftLeft, true
+	case token.ShiftRightAssign:
+		return ast.OpShiftRight, true
+	default:
+		return 0, false
+	}
+}
+
+func isAssignOperator(k token.Kind) bool {
+	_, ok := assignOperator(k)
+	return ok
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/token"
+)
+
+// parseNakedList parses items until end of statement, closing brace, colon,
+// define operator or extraTerminator.  The item parser chooses the production
+// by looking at the next token.  Zero-valued items (separators) are discarded.
+func parseNakedList[T comparable](s scan, extraTerminator token.Kind, item func(scan) (scan, T)) (scan, []T) {
+	var results []T
+
+	for {
+		switch s.peek().Kind {
+		case token.BlockComment, token.BraceRight, token.Colon, token.Comment, token.Define, token.DocComment, token.Newline, token.Semicolon, extraTerminator:
+			return s, results
+		}
+
+		var r T
+		s, r = item(s)
+
+		var zero T
+		if r != zero {
+			results = append(results, r)
+		}
+	}
+}
+
+// parseListUntil parses items until stop succeeds.  Zero-valued items
+// (separators) are discarded.
+func parseListUntil[T comparable](s scan, stop func(scan) (scan, bool), item func(scan) (scan, T)) (scan, []T) {
+	var results []T
+
+	for {
+		if end, ok := stop(s); ok {
+			return end, results
+		}
+
+		var r T
+		s, r = item(s)
+
+		var zero T
+		if r != zero {
+			results = append(results, r)
+		}
+	}
+}
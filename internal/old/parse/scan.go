@@ -0,0 +1,147 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"io"
+
+	"github.com/tsavola/dp/internal/old/token"
+	"github.com/tsavola/dp/source"
+)
+
+// TokenSource is implemented by lex.Scanner.
+type TokenSource interface {
+	// Next token.  io.EOF is returned at the end of input.
+	Next() (token.Token, error)
+}
+
+// errorLister is implemented by lex.Scanner.  It lists non-fatal errors.
+type errorLister interface {
+	Errors() source.ErrorList
+}
+
+// tokenBuffer is filled from source on demand.  Tokens are retained for
+// lookahead and error recovery.
+type tokenBuffer struct {
+	source TokenSource // Nil when all tokens have been buffered.
+	buf    []token.Token
+	err    error // Non-EOF error returned by source.
+	mode   Mode
+}
+
+// get returns false if there are no more tokens.
+func (ts *tokenBuffer) get(i int) (token.Token, bool) {
+	for ts.source != nil && i >= len(ts.buf) {
+		t, err := ts.source.Next()
+		if err != nil {
+			ts.source = nil
+			if err != io.EOF {
+				ts.err = err
+			}
+			break
+		}
+		ts.buf = append(ts.buf, t)
+	}
+
+	if i < len(ts.buf) {
+		return ts.buf[i], true
+	}
+	return token.Token{}, false
+}
+
+// scan state.
+type scan struct {
+	tokens *tokenBuffer
+	index  int
+	last   source.Position
+	errs   source.ErrorList // Recovered errors.
+	header bool             // Composite literal type names are not allowed.
+}
+
+func (s scan) pos() source.Position {
+	s.peek() // Skip space.
+	if _, ok := s.tokens.get(s.index); !ok {
+		return source.Position{}
+	}
+	return s.tokens.buf[s.index].Pos()
+}
+
+// peek at the next token.  Space tokens are skipped.  Last position is not
+// updated.
+func (s *scan) peek() token.Token {
+	var zero token.Token
+
+	for {
+		t, ok := s.tokens.get(s.index)
+		if !ok {
+			return zero
+		}
+		if t.Kind != token.Space {
+			return t
+		}
+		s.index++
+	}
+}
+
+// skip token if it's next.  Space tokens are skipped.  Last position is
+// updated on success.
+func (s *scan) skip(wanted token.Kind) bool {
+	_, ok := s.skim(wanted)
+	return ok
+}
+
+// skim returns token if it's next.  Space tokens are skipped.  Last position
+// is updated on success.
+func (s *scan) skim(wanted token.Kind) (token.Token, bool) {
+	t := s.peek()
+	if t.Kind != wanted {
+		return token.Token{}, false
+	}
+
+	s.index++
+
+	if _, ok := s.tokens.get(s.index); ok {
+		s.last = s.pos()
+	} else {
+		s.last = t.End()
+	}
+
+	return t, true
+}
+
+// take returns token or panics with an error which says what was expected.
+// Space tokens are skipped.  Last position is updated on success.
+func (s *scan) take(wanted token.Kind, what string) token.Token {
+	t, ok := s.skim(wanted)
+	if !ok {
+		unexpected(*s, what)
+	}
+	return t
+}
+
+// lookahead returns the nth token after the next one without consuming
+// anything.  Space tokens are skipped.
+func (s scan) lookahead(n int) token.Token {
+	for {
+		t := s.peek()
+		if n == 0 || t.Kind == 0 {
+			return t
+		}
+		s.index++
+		n--
+	}
+}
+
+func peekEOF(s scan) (scan, bool) {
+	s.peek() // Skip space.
+	_, ok := s.tokens.get(s.index)
+	return s, !ok
+}
+
+func skipper(t token.Kind) func(scan) (scan, bool) {
+	return func(s scan) (scan, bool) {
+		ok := s.skip(t)
+		return s, ok
+	}
+}
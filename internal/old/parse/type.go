@@ -0,0 +1,59 @@
+// Copyright (c) 2022 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"github.com/tsavola/dp/internal/old/ast"
+	"github.com/tsavola/dp/internal/old/token"
+)
+
+func parseType(s scan) (scan, ast.Type) {
+	var t ast.Type
+
+	t.Assigner = s.skip(token.Assign)
+	t.Pointer = s.skip(token.Asterisk)
+	t.Reference = s.skip(token.Ampersand)
+	t.Shared = s.skip(token.Hash)
+
+	if s.skip(token.BracketLeft) {
+		var item ast.Type
+		s, item = parseType(s)
+		t.Item = &item
+		s.take(token.BracketRight, "closing bracket of array type")
+	} else {
+		s, t.Name = parseQualifiedName(s, "type")
+	}
+
+	return s, t
+}
+
+func parseTypeSpec(s scan) (scan, ast.TypeSpec) {
+	pos := s.pos()
+	s, t := parseType(s)
+	return s, ast.TypeSpec{pos, t, s.last}
+}
+
+func parseTypeListChild(s scan) (scan, ast.TypeListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseTypeSpec(s)
+	}
+}
+
+func parseTypeListChildNaked(s scan) (scan, ast.TypeListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+	return parseTypeSpec(s)
+}
+
+func typeSpecified(t ast.TypeSpec) bool {
+	return t.Item != nil || len(t.Name) > 0
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package token enumerates lexical tokens.
+package token
+
+import (
+	"github.com/tsavola/dp/internal/position"
+	"github.com/tsavola/dp/source"
+)
+
+type Token struct {
+	At source.Position
+	Kind
+	Source string
+}
+
+func (t Token) Pos() source.Position {
+	return t.At
+}
+
+// End returns the position immediately after the token.
+func (t Token) End() source.Position {
+	return position.After(t.At, t.Source)
+}
+
+func (t Token) String() string {
+	return t.Kind.String()
+}
+
+// Kind of token.
+type Kind int
+
+const (
+	_ Kind = iota
+
+	Illegal // Invalid input.
+
+	Space // Excluding newline.
+	Newline
+	Comment      // Line comment.
+	BlockComment // Nestable.
+	DocComment   // Line comment starting with exactly three slashes.
+
+	// Keywords
+	Auto
+	Break
+	Case
+	Clone
+	Continue
+	Default
+	Else
+	False
+	For
+	If
+	Import
+	Nil
+	Range
+	Return
+	Switch
+	True
+
+	// Identifier, or keyword at file level or in type definition
+	WordLower
+	WordUpper
+
+	// Basic type literals
+	Integer   // 12345
+	Float     // 1.5
+	Character // 'a'
+	String    // "abc" or `abc`
+
+	// Operators and delimiters
+	Plus     // +
+	Minus    // -
+	Asterisk // *
+	Slash    // /
+	Percent  // %
+
+	LogicalAnd // &&
+	LogicalOr  // ||
+
+	AndNot    // &^
+	Ampersand // &
+	Pipe      // |
+	Caret     // ^
+
+	ShiftLeft  // <<
+	ShiftRight // >>
+
+	Equal          // ==
+	NotEqual       // !=
+	LessOrEqual    // <=
+	GreaterOrEqual // >=
+	Less           // <
+	Greater        // >
+
+	Exclamation // !
+
+	Assign // =
+	Define // :=
+
+	PlusAssign       // +=
+	MinusAssign      // -=
+	AsteriskAssign   // *=
+	SlashAssign      // /=
+	PercentAssign    // %=
+	AndNotAssign     // &^=
+	AmpersandAssign  // &=
+	PipeAssign       // |=
+	CaretAssign      // ^=
+	ShiftLeftAssign  // <<=
+	ShiftRightAssign // >>=
+
+	Comma     // ,
+	Period    // .
+	Semicolon // ;
+	Colons    // ::
+	Colon     // :
+	Hash      // #
+
+	ParenLeft   // (
+	BracketLeft // [
+	BraceLeft   // {
+
+	ParenRight   // )
+	BracketRight // ]
+	BraceRight   // }
+)
+
+func (k Kind) String() string {
+	s := strings[k]
+	if s == "" {
+		return "<invalid token>"
+	}
+	return s
+}
+
+var strings = [...]string{
+	Illegal: "Illegal",
+
+	Space:        "Space",
+	Newline:      "Newline",
+	Comment:      "Comment",
+	BlockComment: "BlockComment",
+	DocComment:   "DocComment",
+
+	Auto:     "auto",
+	Break:    "break",
+	Case:     "case",
+	Clone:    "clone",
+	Continue: "continue",
+	Default:  "default",
+	Else:     "else",
+	False:    "false",
+	For:      "for",
+	If:       "if",
+	Nil:      "nil",
+	Range:    "range",
+	Return:   "return",
+	Switch:   "switch",
+	True:     "true",
+
+	WordLower: "WordLower",
+	WordUpper: "WordUpper",
+
+	Integer:   "Integer",
+	Float:     "Float",
+	Character: "Character",
+	String:    "String",
+
+	Plus:     "+",
+	Minus:    "-",
+	Asterisk: "*",
+	Slash:    "/",
+	Percent:  "%",
+
+	LogicalAnd: "&&",
+	LogicalOr:  "||",
+
+	AndNot:    "&^",
+	Ampersand: "&",
+	Pipe:      "|",
+	Caret:     "^",
+
+	ShiftLeft:  "<<",
+	ShiftRight: ">>",
+
+	Equal:          "==",
+	NotEqual:       "!=",
+	LessOrEqual:    "<=",
+	GreaterOrEqual: ">=",
+	Less:           "<",
+	Greater:        ">",
+
+	Exclamation: "!",
+
+	Assign: "=",
+	Define: ":=",
+
+	PlusAssign:       "+=",
+	MinusAssign:      "-=",
+	AsteriskAssign:   "*=",
+	SlashAssign:      "/=",
+	PercentAssign:    "%=",
+	AndNotAssign:     "&^=",
+	AmpersandAssign:  "&=",
+	PipeAssign:       "|=",
+	CaretAssign:      "^=",
+	ShiftLeftAssign:  "<<=",
+	ShiftRightAssign: ">>=",
+
+	Comma:     ",",
+	Period:    ".",
+	Semicolon: ";",
+	Colons:    "::",
+	Colon:     ":",
+	Hash:      "#",
+
+	ParenLeft:   "(",
+	BracketLeft: "[",
+	BraceLeft:   "{",
+
+	ParenRight:   ")",
+	BracketRight: "]",
+	BraceRight:   "}",
+}
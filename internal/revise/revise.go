@@ -5,20 +5,24 @@ package revise
 
 import (
 	new "github.com/tsavola/dp/ast"
-	old "github.com/tsavola/dp/ast"
 	newfield "github.com/tsavola/dp/field"
+	old "github.com/tsavola/dp/internal/old/ast"
+	oldlex "github.com/tsavola/dp/internal/old/lex"
+	oldparse "github.com/tsavola/dp/internal/old/parse"
+	oldtoken "github.com/tsavola/dp/internal/old/token"
 	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/internal/sync"
-	oldlex "github.com/tsavola/dp/lex"
-	oldparse "github.com/tsavola/dp/parse"
 	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
 
 	. "github.com/tsavola/dp/internal/pan/mustcheck"
 )
 
 func File(pos source.Position, input string) (news []new.FileChild, err error) {
 	err = pan.Recover(func() {
-		news = reviseFile(Must(oldparse.File(Must(oldlex.File(pos, input)))))
+		s := oldlex.NewStringScanner(pos, input)
+		s.Mode = oldlex.LenientNames
+		news = reviseFile(Must(oldparse.Stream(s)))
 	})
 	return
 }
@@ -57,11 +61,16 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 				news = append(news, new.Assign{
 					node.At,
 					reviseAssignList(node.Targets),
+					reviseBinaryOp(node.Op),
 					reviseExprList(node.Values),
 					node.EndAt,
 				})
 			},
 
+			func(node old.BadStmt) {
+				news = append(news, new.BadStmt{node.At, node.Source})
+			},
+
 			func(node old.Block) {
 				news = append(news, new.Block{
 					node.At,
@@ -71,7 +80,7 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 			},
 
 			func(node old.Break) {
-				news = append(news, new.Break{node.At, node.EndAt})
+				news = append(news, new.Break{node.At, node.Label, node.EndAt})
 			},
 
 			func(node old.Comment) {
@@ -79,7 +88,7 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 			},
 
 			func(node old.Continue) {
-				news = append(news, new.Continue{node.At, node.EndAt})
+				news = append(news, new.Continue{node.At, node.Label, node.EndAt})
 			},
 
 			func(node old.Expression) {
@@ -89,7 +98,11 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 			func(node old.For) {
 				news = append(news, new.For{
 					node.At,
+					node.Label,
+					reviseStatement(node.Init),
 					reviseExpr(node.Test),
+					reviseStatement(node.Post),
+					reviseForRange(node.Range),
 					node.BodyAt,
 					reviseBlock(node.Body),
 					node.EndAt,
@@ -97,15 +110,7 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 			},
 
 			func(node old.If) {
-				news = append(news, new.If{
-					node.At,
-					reviseExpr(node.Test),
-					node.ThenAt,
-					reviseBlock(node.Then),
-					node.ThenEndAt,
-					reviseBlock(node.Else),
-					node.EndAt,
-				})
+				news = append(news, reviseIf(node))
 			},
 
 			func(node old.Import) {
@@ -120,6 +125,16 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 				})
 			},
 
+			func(node old.Switch) {
+				news = append(news, new.Switch{
+					node.At,
+					reviseExpr(node.Value),
+					node.BodyAt,
+					reviseCaseList(node.Cases),
+					node.EndAt,
+				})
+			},
+
 			func(node old.VariableDecl) {
 				news = append(news, new.VariableDecl{
 					node.At,
@@ -142,7 +157,41 @@ func reviseBlock(olds []old.BlockChild) (news []new.BlockChild) {
 	return
 }
 
+func reviseCaseList(olds []old.CaseListChild) (news []new.CaseListChild) {
+	for _, node := range olds {
+		old.VisitCaseListChild(node,
+			func(node old.Case) {
+				news = append(news, new.Case{
+					node.At,
+					node.Default,
+					reviseExprList(node.Values),
+					reviseBlock(node.Body),
+					node.EndAt,
+				})
+			},
+
+			func(node old.Comment) {
+				news = append(news, new.Comment{node.At, node.Source})
+			},
+		)
+	}
+	return
+}
+
+// reviseStatement returns nil if node is nil.
+func reviseStatement(node old.BlockChild) new.BlockChild {
+	if node == nil {
+		return nil
+	}
+	return reviseBlock([]old.BlockChild{node})[0]
+}
+
+// reviseExpr returns nil if node is nil.
 func reviseExpr(node old.ExprChild) (result new.ExprChild) {
+	if node == nil {
+		return
+	}
+
 	old.VisitExpr(node,
 		func(node old.Address) {
 			result = new.Address{
@@ -155,7 +204,7 @@ func reviseExpr(node old.ExprChild) (result new.ExprChild) {
 		func(node old.Binary) {
 			result = new.Binary{
 				reviseExpr(node.Left),
-				new.BinaryOp(node.Op),
+				reviseBinaryOp(node.Op),
 				reviseExpr(node.Right),
 				node.EndAt,
 			}
@@ -185,10 +234,23 @@ func reviseExpr(node old.ExprChild) (result new.ExprChild) {
 			}
 		},
 
+		func(node old.CompositeLit) {
+			result = new.CompositeLit{
+				node.At,
+				reviseType(node.Type),
+				reviseElemList(node.Elems),
+				node.EndAt,
+			}
+		},
+
 		func(node old.Empty) {
 			result = new.Empty{node.At, node.EndAt}
 		},
 
+		func(node old.Float) {
+			result = new.Float{node.At, node.Source}
+		},
+
 		func(node old.Index) {
 			result = reviseIndex(node)
 		},
@@ -220,7 +282,7 @@ func reviseExpr(node old.ExprChild) (result new.ExprChild) {
 		func(node old.Unary) {
 			result = new.Unary{
 				node.At,
-				new.UnaryOp(node.Op),
+				reviseUnaryOp(node.Op),
 				reviseExpr(node.Expr),
 				node.EndAt,
 			}
@@ -229,6 +291,25 @@ func reviseExpr(node old.ExprChild) (result new.ExprChild) {
 	return
 }
 
+func reviseElemList(olds []old.ElemListChild) (news []new.ElemListChild) {
+	for _, node := range olds {
+		old.VisitElemListChild(node,
+			func(node old.Comment) {
+				news = append(news, new.Comment{node.At, node.Source})
+			},
+
+			func(node old.Expression) {
+				news = append(news, new.Expression{reviseExpr(node.Expr)})
+			},
+
+			func(node old.KeyValue) {
+				news = append(news, new.KeyValue{node.At, node.Key, reviseExpr(node.Value)})
+			},
+		)
+	}
+	return
+}
+
 func reviseExprList(olds []old.ExprListChild) (news []new.ExprListChild) {
 	for _, node := range olds {
 		old.VisitExprListChild(node,
@@ -270,6 +351,10 @@ func reviseFieldList(olds []old.FieldListChild) (news []new.FieldListChild) {
 func reviseFile(olds []old.FileChild) (news []new.FileChild) {
 	for _, node := range olds {
 		old.VisitFileChild(node,
+			func(node old.BadDecl) {
+				news = append(news, new.BadDecl{node.At, node.Source})
+			},
+
 			func(node old.Comment) {
 				news = append(news, new.Comment{node.At, node.Source})
 			},
@@ -291,6 +376,7 @@ func reviseFile(olds []old.FileChild) (news []new.FileChild) {
 					node.ReceiverName,
 					reviseTypeSpecPtr(node.ReceiverType),
 					node.FuncName,
+					nil,
 					reviseParamList(node.Params),
 					node.ParamsEndAt,
 					reviseTypeList(node.Results),
@@ -317,6 +403,7 @@ func reviseFile(olds []old.FileChild) (news []new.FileChild) {
 					node.At,
 					node.Public,
 					node.TypeName,
+					nil,
 					reviseFieldList(node.Fields),
 					node.EndAt,
 				})
@@ -405,6 +492,38 @@ func reviseField(node old.Field) new.Field {
 	}
 }
 
+func reviseForRange(p *old.ForRange) *new.ForRange {
+	if p == nil {
+		return nil
+	}
+	return &new.ForRange{
+		p.At,
+		p.Names,
+		reviseExpr(p.Expr),
+		p.EndAt,
+	}
+}
+
+// reviseIf converts else block which contains only an if statement into an
+// else-if chain.
+func reviseIf(node old.If) new.If {
+	elseIf := node.ElseIf
+	if len(node.Else) == 1 {
+		_, elseIf = node.Else[0].(old.If)
+	}
+
+	return new.If{
+		node.At,
+		reviseExpr(node.Test),
+		node.ThenAt,
+		reviseBlock(node.Then),
+		node.ThenEndAt,
+		elseIf,
+		reviseBlock(node.Else),
+		node.EndAt,
+	}
+}
+
 func reviseImport(node old.Import) new.Import {
 	return new.Import{
 		node.At,
@@ -455,6 +574,23 @@ func reviseSelector(node old.Selector) new.Selector {
 	}
 }
 
+// reviseOp converts operator token kind.  Operators are enumerated in the same
+// order in both versions, but new keywords may have shifted them.
+func reviseOp(k oldtoken.Kind) token.Kind {
+	if k == 0 {
+		return 0
+	}
+	return token.Plus + token.Kind(k-oldtoken.Plus)
+}
+
+func reviseBinaryOp(op old.BinaryOp) new.BinaryOp {
+	return new.BinaryOp(reviseOp(oldtoken.Kind(op)))
+}
+
+func reviseUnaryOp(op old.UnaryOp) new.UnaryOp {
+	return new.UnaryOp(reviseOp(oldtoken.Kind(op)))
+}
+
 func reviseType(t old.Type) new.Type {
 	return new.Type{
 		t.Assigner,
@@ -462,7 +598,9 @@ func reviseType(t old.Type) new.Type {
 		t.Reference,
 		t.Shared,
 		reviseTypePtr(t.Item),
+		nil,
 		new.QualifiedName(t.Name),
+		nil,
 	}
 }
 
@@ -4,6 +4,7 @@
 package lex
 
 import (
+	"github.com/tsavola/dp/internal/literal"
 	"github.com/tsavola/dp/internal/position"
 	"github.com/tsavola/dp/source"
 )
@@ -15,3 +16,24 @@ func decodeError(pos source.Position) error {
 func tokenError(s scan) error {
 	return position.NewError(s.pos(), "illegal token")
 }
+
+func commentError(start scan) error {
+	return position.NewError(start.pos(), "unterminated block comment")
+}
+
+func numberError(s scan, format string, args ...any) error {
+	return position.Errorf(s.pos(), format, args...)
+}
+
+func literalError(start scan, source string, e *literal.Error) error {
+	return position.NewError(position.After(start.pos(), source[:e.Offset]), e.Msg)
+}
+
+// spanError is raised when the end of the invalid token is known.
+type spanError struct {
+	err error
+	end scan
+}
+
+func (e spanError) Error() string         { return e.err.Error() }
+func (e spanError) PositionError() string { return e.err.(position.Error).PositionError() }
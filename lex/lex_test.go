@@ -0,0 +1,458 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package lex
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
+)
+
+var equivalenceInputs = []string{
+	"",
+	" \t\v\f\r\u00a0x\n",
+	"auto break case clone continue default defer else false for func if import nil range return switch true",
+	"autos broken iffy for_ if2 if_ true\u00e4",
+	"Upper lower _under \u01c5title \u00c4ber \u00e4ber",
+	"0123 12 0 00",
+	"'a' '\\'' \"a\\\"b\" `a\\`b` \"multi\nline\"",
+	"+ - * / % && || &^ & | ^ << >> == != <= >= < > ! = := , . ; :: : # ( [ { ) ] }",
+	"&&& &^^ <<= >>= === !== ::= ://",
+	"+= -= *= /= %= &^= &= |= ^= <<= >>= &&= ||= &^^= <<<= /=/ //=",
+	"// comment\n//\n/ /\n",
+	"x\x00y \"\x00\" // \x00\n",
+	"\xff",
+	"a \xff",
+	"ab\xff",
+	"if\xff",
+	"12\xff",
+	" \xff",
+	"// \xff",
+	"x //\xff\n",
+	"\"\xff\"",
+	"&\xff",
+	"/\xff",
+	":\xff",
+	"\ufffd",
+	"@",
+	"x $",
+	"'abc",
+	"\"abc\\",
+	"\"abc\\\xff\"",
+	"`",
+}
+
+func TestEquivalence(t *testing.T) {
+	inputs := append(equivalenceInputs, generateSource(50))
+
+	for i, input := range inputs {
+		pos := source.Location(fmt.Sprint("input", i))
+
+		expectTokens, expectErr := trialFile(pos, input)
+		tokens, err := lenientFile(pos, input)
+
+		if !slices.Equal(tokens, expectTokens) {
+			t.Errorf("input %d %q:\n  tokens:   %v\n  expected: %v", i, input, tokens, expectTokens)
+		}
+		if fmt.Sprint(err) != fmt.Sprint(expectErr) || errorString(err) != errorString(expectErr) {
+			t.Errorf("input %d %q:\n  error:    %v\n  expected: %v", i, input, errorString(err), errorString(expectErr))
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		kind  token.Kind
+		err   string
+	}{
+		{"0", token.Integer, ""},
+		{"0123", token.Integer, ""},
+		{"1_000_000", token.Integer, ""},
+		{"0x1F", token.Integer, ""},
+		{"0X_ff_ff", token.Integer, ""},
+		{"0o17", token.Integer, ""},
+		{"0b1010_1010", token.Integer, ""},
+		{"1.5", token.Float, ""},
+		{"1e10", token.Float, ""},
+		{"1.5E-3", token.Float, ""},
+		{"1_0.0_1e+1_0", token.Float, ""},
+		{"0x1p-2", token.Float, ""},
+		{"0x1.8P3", token.Float, ""},
+		{"0x", 0, "003: hexadecimal literal has no digits"},
+		{"0b", 0, "003: binary literal has no digits"},
+		{"0b102", 0, "005: invalid digit '2' in binary literal"},
+		{"0o8", 0, "003: invalid digit '8' in octal literal"},
+		{"12ab", 0, "003: invalid character 'a' in decimal literal"},
+		{"0x1g", 0, "004: invalid character 'g' in hexadecimal literal"},
+		{"1__0", 0, "003: '_' must separate successive digits"},
+		{"1_", 0, "003: '_' must separate successive digits"},
+		{"0x_", 0, "004: '_' must separate successive digits"},
+		{"1e", 0, "003: exponent has no digits"},
+		{"1e+", 0, "004: exponent has no digits"},
+		{"0x1.8", 0, "006: hexadecimal mantissa requires p exponent"},
+		{"\u0663", 0, "001: illegal token"},
+	} {
+		tokens, err := File(source.Location(""), x.input)
+		if x.err != "" {
+			if err == nil {
+				t.Errorf("%q: no error", x.input)
+			} else if s := errorString(err); s != "0001:"+x.err {
+				t.Errorf("%q: %s", x.input, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", x.input, err)
+		} else if len(tokens) != 1 || tokens[0].Kind != x.kind || tokens[0].Source != x.input {
+			t.Errorf("%q: %v", x.input, tokens)
+		}
+	}
+}
+
+func TestQuoted(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		err   string
+	}{
+		{`'a'`, ""},
+		{`'\''`, ""},
+		{`'\x7f'`, ""},
+		{`'\u{1F600}'`, ""},
+		{`'ä'`, ""},
+		{`"a\n\t\\\"\xff\u{e4}"`, ""},
+		{"`a\\q`", ""},
+		{"`a\\`b`", ""},
+		{`''`, "001: empty character literal"},
+		{`'ab'`, "003: character literal contains multiple characters"},
+		{`'\q'`, "002: unknown escape sequence"},
+		{`"a\q"`, "003: unknown escape sequence"},
+		{`"\x"`, "002: hexadecimal escape sequence requires two digits"},
+		{`"\x4g"`, "002: hexadecimal escape sequence requires two digits"},
+		{`"\u0041"`, "002: unicode escape sequence must be enclosed in \\u{...}"},
+		{`"\u{}"`, "002: unicode escape sequence requires one to six digits"},
+		{`"\u{12g}"`, "007: invalid digit in unicode escape sequence"},
+		{`"\u{D800}"`, "002: unicode escape sequence is not a valid code point"},
+		{"\"x\ny\\q\"", "0002:002: unknown escape sequence"},
+	} {
+		_, err := File(source.Location(""), x.input)
+		if x.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", x.input, err)
+			}
+		} else if err == nil {
+			t.Errorf("%s: no error", x.input)
+		} else if s := errorString(err); s != "0001:"+x.err && s != x.err {
+			t.Errorf("%s: %s", x.input, s)
+		}
+	}
+}
+
+func TestComment(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		kinds []token.Kind
+	}{
+		{"// x\n", []token.Kind{token.Comment, token.Newline}},
+		{"/// x", []token.Kind{token.DocComment}},
+		{"//// x", []token.Kind{token.Comment}},
+		{"/* x */ a", []token.Kind{token.BlockComment, token.Space, token.WordLower}},
+		{"/* a /* b */ c */", []token.Kind{token.BlockComment}},
+		{"/*\n*/\n", []token.Kind{token.BlockComment, token.Newline}},
+		{"a / b", []token.Kind{token.WordLower, token.Space, token.Slash, token.Space, token.WordLower}},
+		{"/* a", nil},
+		{"/* a /* b */", nil},
+		{"/* a /* b */ c\n*", nil},
+	} {
+		tokens, err := File(source.Location(""), x.input)
+		if x.kinds == nil {
+			if err == nil {
+				t.Errorf("%q: no error", x.input)
+			} else if s := errorString(err); s != "0001:001: unterminated block comment" {
+				t.Errorf("%q: %s", x.input, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", x.input, err)
+			continue
+		}
+
+		var kinds []token.Kind
+		for _, t := range tokens {
+			kinds = append(kinds, t.Kind)
+		}
+		if !slices.Equal(kinds, x.kinds) {
+			t.Errorf("%q: %v", x.input, kinds)
+		}
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		kinds []token.Kind
+		crlf  bool
+	}{
+		{"a\nb", []token.Kind{token.WordLower, token.Newline, token.WordLower}, false},
+		{"a\r\nb", []token.Kind{token.WordLower, token.Newline, token.WordLower}, true},
+		{"a \r\n", []token.Kind{token.WordLower, token.Space, token.Newline}, true},
+		{"a\rb", []token.Kind{token.WordLower, token.Space, token.WordLower}, false},
+		{"// c\r\n", []token.Kind{token.Comment, token.Newline}, true},
+		{"\ufeffa", []token.Kind{token.WordLower}, false},
+		{"#!/bin/dp\na", []token.Kind{token.Comment, token.Newline, token.WordLower}, false},
+		{"\ufeff#!/bin/dp\r\n", []token.Kind{token.Comment, token.Newline}, true},
+	} {
+		s := NewStringScanner(source.Location(""), x.input)
+
+		var kinds []token.Kind
+		for {
+			tok, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", x.input, err)
+			}
+			if tok.Kind == token.Comment && strings.ContainsAny(tok.Source, "\r\n") {
+				t.Errorf("%q: comment contains line ending", x.input)
+			}
+			kinds = append(kinds, tok.Kind)
+		}
+		if !slices.Equal(kinds, x.kinds) {
+			t.Errorf("%q: %v", x.input, kinds)
+		}
+		if s.CRLF() != x.crlf {
+			t.Errorf("%q: CRLF reported as %v", x.input, s.CRLF())
+		}
+	}
+
+	for _, input := range []string{"a\ufeff", "a\n\ufeff"} {
+		if _, err := File(source.Location(""), input); err == nil {
+			t.Errorf("%q: no error", input)
+		}
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	for _, x := range []struct {
+		input   string
+		illegal []string
+		errs    []string
+	}{
+		{"a $ b", []string{"$"}, []string{"0001:003: illegal token"}},
+		{"a $$ b", []string{"$$"}, []string{"0001:003: illegal token"}},
+		{"0x + 1.5e\n2", []string{"0x", "1.5e"}, []string{"0001:003: hexadecimal literal has no digits", "0001:010: exponent has no digits"}},
+		{`"\q" + 'ab'`, []string{`"\q"`, "'ab'"}, []string{"0001:002: unknown escape sequence", "0001:010: character literal contains multiple characters"}},
+		{"\"abc", []string{`"`}, []string{"0001:001: illegal token"}},
+		{"a\xff\xfeb c", []string{"a\xff\xfeb"}, []string{"0001:001: illegal token"}},
+		{"\xff", []string{"\xff"}, []string{"0001:001: invalid UTF-8 encoding"}},
+		{"fooBar", nil, []string{"0001:004: upper-case letter in name"}},
+		{"\ufeff$", []string{"$"}, []string{"0001:001: illegal token"}},
+		{"a /* b /* c */\nd", []string{"/* b /* c */\nd"}, []string{"0001:003: unterminated block comment"}},
+	} {
+		s := NewStringScanner(source.Location(""), x.input)
+		s.Mode = AllErrors
+
+		var (
+			illegal []string
+			text    string
+		)
+		for {
+			tok, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", x.input, err)
+			}
+			if tok.Kind == token.Illegal {
+				illegal = append(illegal, tok.Source)
+			}
+			text += tok.Source
+		}
+
+		var errs []string
+		for _, err := range s.Errors() {
+			errs = append(errs, errorString(err))
+		}
+
+		if !slices.Equal(illegal, x.illegal) {
+			t.Errorf("%q: illegal tokens: %q", x.input, illegal)
+		}
+		if !slices.Equal(errs, x.errs) {
+			t.Errorf("%q: errors: %q", x.input, errs)
+		}
+		if !strings.HasSuffix(x.input, text) {
+			t.Errorf("%q: tokens don't cover input: %q", x.input, text)
+		}
+	}
+
+	tokens, err := FileMode(source.Location(""), "a $ b", AllErrors)
+	if len(tokens) != 5 {
+		t.Errorf("FileMode: %d tokens", len(tokens))
+	}
+	if list, ok := err.(source.ErrorList); !ok || len(list) != 1 {
+		t.Errorf("FileMode: %v", err)
+	}
+}
+
+func TestName(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		err   string
+	}{
+		{"_", ""},
+		{"_x", ""},
+		{"snake_case_name", ""},
+		{"Upper", ""},
+		{"I32", ""},
+		{"HTTPRequest", ""},
+		{"x2", "002: digit in name"},
+		{"a__b", "003: consecutive underscores in name"},
+		{"a_", "002: trailing underscore in name"},
+		{"camelCase", "006: upper-case letter in name"},
+		{"Snake_Case", "006: underscore in type name"},
+	} {
+		_, err := File(source.Location(""), x.input)
+		if x.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", x.input, err)
+			}
+		} else if err == nil {
+			t.Errorf("%s: no error", x.input)
+		} else if s := errorString(err); s != "0001:"+x.err {
+			t.Errorf("%s: %s", x.input, s)
+		}
+
+		s := NewStringScanner(source.Location(""), x.input)
+		s.Mode = LenientNames
+		if _, err := s.Next(); err != nil {
+			t.Errorf("%s: lenient: %v", x.input, err)
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	inputs := append(equivalenceInputs, generateSource(50))
+
+	for i, input := range inputs {
+		pos := source.Location(fmt.Sprint("input", i))
+
+		expectTokens, expectErr := File(pos, input)
+
+		var (
+			s      = NewScanner(pos, iotest.OneByteReader(strings.NewReader(input)))
+			tokens []token.Token
+			err    error
+		)
+		for {
+			var tok token.Token
+			if tok, err = s.Next(); err != nil {
+				break
+			}
+			tokens = append(tokens, tok)
+		}
+		if err == io.EOF {
+			err = nil
+		} else {
+			tokens = nil
+		}
+
+		if !slices.Equal(tokens, expectTokens) {
+			t.Errorf("input %d %q:\n  tokens:   %v\n  expected: %v", i, input, tokens, expectTokens)
+		}
+		if errorString(err) != errorString(expectErr) {
+			t.Errorf("input %d %q:\n  error:    %v\n  expected: %v", i, input, errorString(err), errorString(expectErr))
+		}
+	}
+}
+
+func lenientFile(pos source.Position, text string) (tokens []token.Token, err error) {
+	s := NewStringScanner(pos, text)
+	s.Mode = LenientNames
+
+	for {
+		var t token.Token
+		if t, err = s.Next(); err != nil {
+			if err == io.EOF {
+				return tokens, nil
+			}
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+func errorString(err error) string {
+	return fmt.Sprint(source.ErrorWithPositionPrefix(err, ""))
+}
+
+func BenchmarkFile(b *testing.B) {
+	benchmarkFile(b, File)
+}
+
+func BenchmarkTrialFile(b *testing.B) {
+	benchmarkFile(b, trialFile)
+}
+
+func benchmarkFile(b *testing.B, tokenize func(source.Position, string) ([]token.Token, error)) {
+	input := generateSource(1000)
+	pos := source.Location("benchmark")
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := tokenize(pos, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// generateSource returns a program with the given number of functions.
+func generateSource(functions int) string {
+	var b strings.Builder
+
+	for i := range functions {
+		name := generateName(i)
+
+		fmt.Fprintf(&b, `// Function %[1]s computes something.
+pub compute_%[1]s(x I32, y I32) (I32, Bool) {
+	total := 0
+	for total < x {
+		total = total + y*2 - (x >> 1) // Adjust.
+		if total == 100 && !done(&x) {
+			break
+		}
+	}
+
+	text := "item \"%[1]s\""
+	c := 'x'
+	values[total] = ::pkg::convert(text, c)
+	return total, x != y
+}
+
+`, name)
+	}
+
+	return b.String()
+}
+
+func generateName(i int) string {
+	s := ""
+	for {
+		s = string(rune('a'+i%26)) + s
+		i /= 26
+		if i == 0 {
+			return s
+		}
+	}
+}
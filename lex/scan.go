@@ -4,33 +4,93 @@
 package lex
 
 import (
+	"io"
 	"unicode/utf8"
 
 	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/source"
 )
 
+// invalid is returned by peek when the next rune is not encoded correctly.
+const invalid = -1
+
+const readSize = 16384
+
+// input buffer which is shared by scan states.
+type input struct {
+	r      io.Reader // Nil when all input has been buffered.
+	buf    []byte
+	text   string
+	offset int // Byte offset of text.
+	keep   int // Byte offset of the earliest text which may still be accessed.
+}
+
+// ensure that n bytes are buffered after byte offset, if there is enough
+// input.
+func (in *input) ensure(offset, n int) {
+	for in.r != nil && offset+n > in.offset+len(in.text) {
+		in.fill()
+	}
+}
+
+func (in *input) fill() {
+	if in.buf == nil {
+		in.buf = make([]byte, readSize)
+	}
+
+	n, err := in.r.Read(in.buf)
+
+	in.text = in.text[in.keep-in.offset:] + string(in.buf[:n])
+	in.offset = in.keep
+
+	if err != nil {
+		in.r = nil
+		in.buf = nil
+		if err != io.EOF {
+			pan.Panic(err)
+		}
+	}
+}
+
 // scan state.
 type scan struct {
 	source.Position
-	text string
+	in *input
 }
 
 func (s scan) pos() source.Position {
 	return s.Position
 }
 
-// peek returns 0 on EOF.  Zero code point is returned as utf8.RuneError.
+// peek returns 0 on EOF and invalid on encoding error.  Zero code point is
+// returned as utf8.RuneError.
 func (s *scan) peek() rune {
 	r, _ := s.peekSize()
 	return r
 }
 
-// advance must only be called after peek returned a nonzero rune.
+// peekWithin a token which started at start.  Encoding error fails the token.
+func (s *scan) peekWithin(start scan) rune {
+	r := s.peek()
+	if r == invalid {
+		pan.Panic(tokenError(start))
+	}
+	return r
+}
+
+// hasPrefix reports if the remaining text starts with prefix.
+func (s *scan) hasPrefix(prefix string) bool {
+	s.in.ensure(s.ByteOffset, len(prefix))
+
+	rest := s.in.text[s.ByteOffset-s.in.offset:]
+	return len(rest) >= len(prefix) && rest[:len(prefix)] == prefix
+}
+
+// advance must only be called after peek returned a valid, nonzero rune.
 func (s *scan) advance() {
 	c, n := s.peekSize()
-	if c == 0 {
-		panic("advanced on EOF")
+	if c <= 0 {
+		panic("advanced on EOF or encoding error")
 	}
 
 	if c == '\n' {
@@ -42,18 +102,39 @@ func (s *scan) advance() {
 	s.ByteOffset += n
 }
 
-// peekSize returns 0 on EOF.  Zero code point is returned as utf8.RuneError.
+// skipByte must only be called after peek returned invalid.
+func (s *scan) skipByte() {
+	s.Column++
+	s.ByteOffset++
+}
+
+// advanceASCII must only be called after hasPrefix returned true for an ASCII
+// string without newlines.
+func (s *scan) advanceASCII(n int) {
+	s.Column += n
+	s.ByteOffset += n
+}
+
+// peekSize returns 0 on EOF and invalid on encoding error.  Zero code point
+// is returned as utf8.RuneError.
 func (s *scan) peekSize() (rune, int) {
-	if s.ByteOffset == len(s.text) {
+	s.in.ensure(s.ByteOffset, utf8.UTFMax)
+
+	i := s.ByteOffset - s.in.offset
+	if i == len(s.in.text) {
 		return 0, 0
 	}
 
-	c, n := utf8.DecodeRuneInString(s.text[s.ByteOffset:])
-	if c == utf8.RuneError {
-		pan.Panic(decodeError(s.pos()))
+	if b := s.in.text[i]; b < utf8.RuneSelf {
+		if b == 0 {
+			return utf8.RuneError, 1
+		}
+		return rune(b), 1
 	}
-	if c == 0 {
-		return utf8.RuneError, n
+
+	c, n := utf8.DecodeRuneInString(s.in.text[i:])
+	if c == utf8.RuneError {
+		return invalid, n
 	}
 
 	return c, n
@@ -61,5 +142,5 @@ func (s *scan) peekSize() (rune, int) {
 
 // until returns a part of the source.
 func (s *scan) until(endByteOffset int) string {
-	return s.text[s.ByteOffset:endByteOffset]
+	return s.in.text[s.ByteOffset-s.in.offset : endByteOffset-s.in.offset]
 }
@@ -5,223 +5,424 @@
 package lex
 
 import (
+	"io"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/tsavola/dp/internal/literal"
 	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/source"
 	"github.com/tsavola/dp/token"
 )
 
+var keywords = map[string]token.Kind{
+	"auto":     token.Auto,
+	"break":    token.Break,
+	"case":     token.Case,
+	"clone":    token.Clone,
+	"continue": token.Continue,
+	"default":  token.Default,
+	"defer":    token.Defer,
+	"else":     token.Else,
+	"false":    token.False,
+	"for":      token.For,
+	"func":     token.Func,
+	"if":       token.If,
+	"import":   token.Import,
+	"nil":      token.Nil,
+	"range":    token.Range,
+	"return":   token.Return,
+	"switch":   token.Switch,
+	"true":     token.True,
+}
+
+const bom = "\uFEFF"
+
+type operator struct {
+	source string
+	kind   token.Kind
+}
+
+// operators indexed by first character.  Longer alternatives come first.
+var operators = [utf8.RuneSelf][]operator{
+	'+': {{"+=", token.PlusAssign}, {"+", token.Plus}},
+	'-': {{"-=", token.MinusAssign}, {"-", token.Minus}},
+	'*': {{"*=", token.AsteriskAssign}, {"*", token.Asterisk}},
+	'/': {{"/=", token.SlashAssign}, {"/", token.Slash}},
+	'%': {{"%=", token.PercentAssign}, {"%", token.Percent}},
+
+	'&': {{"&&", token.LogicalAnd}, {"&^=", token.AndNotAssign}, {"&^", token.AndNot}, {"&=", token.AmpersandAssign}, {"&", token.Ampersand}},
+	'|': {{"||", token.LogicalOr}, {"|=", token.PipeAssign}, {"|", token.Pipe}},
+	'^': {{"^=", token.CaretAssign}, {"^", token.Caret}},
+
+	'<': {{"<<=", token.ShiftLeftAssign}, {"<<", token.ShiftLeft}, {"<=", token.LessOrEqual}, {"<", token.Less}},
+	'>': {{">>=", token.ShiftRightAssign}, {">>", token.ShiftRight}, {">=", token.GreaterOrEqual}, {">", token.Greater}},
+
+	'=': {{"==", token.Equal}, {"=", token.Assign}},
+	'!': {{"!=", token.NotEqual}, {"!", token.Exclamation}},
+
+	',': {{",", token.Comma}},
+	'.': {{".", token.Period}},
+	';': {{";", token.Semicolon}},
+	':': {{":=", token.Define}, {"::", token.Colons}, {":", token.Colon}},
+	'#': {{"#", token.Hash}},
+
+	'(': {{"(", token.ParenLeft}},
+	'[': {{"[", token.BracketLeft}},
+	'{': {{"{", token.BraceLeft}},
+
+	')': {{")", token.ParenRight}},
+	']': {{"]", token.BracketRight}},
+	'}': {{"}", token.BraceRight}},
+}
+
+// File tokenizes the whole text which is located at pos.
 func File(pos source.Position, text string) ([]token.Token, error) {
-	var tokens []token.Token
-
-	err := pan.Recover(func() {
-		tokens = tokenize(scan{pos, text})
-	})
-
-	return tokens, err
-}
-
-func tokenize(s scan) (tokens []token.Token) {
-next:
-	for s.peek() != 0 {
-		for _, f := range []func(scan) (scan, token.Token){
-			tokenizeSpace,
-			tokenizer(token.Newline, "\n", nil),
-			tokenizeComment,
-
-			tokenizer(token.Auto, "auto", wordRune),
-			tokenizer(token.Break, "break", wordRune),
-			tokenizer(token.Clone, "clone", wordRune),
-			tokenizer(token.Continue, "continue", wordRune),
-			tokenizer(token.Else, "else", wordRune),
-			tokenizer(token.False, "false", wordRune),
-			tokenizer(token.For, "for", wordRune),
-			tokenizer(token.If, "if", wordRune),
-			tokenizer(token.Import, "import", wordRune),
-			tokenizer(token.Nil, "nil", wordRune),
-			tokenizer(token.Return, "return", wordRune),
-			tokenizer(token.True, "true", wordRune),
-
-			wordTokenizer(token.WordLower, false),
-			wordTokenizer(token.WordUpper, true),
-
-			tokenizeInteger,
-			quoteTokenizer(token.Character, '\''),
-			quoteTokenizer(token.String, '"'),
-			quoteTokenizer(token.String, '`'),
-
-			tokenizer(token.Plus, "+", nil),
-			tokenizer(token.Minus, "-", nil),
-			tokenizer(token.Asterisk, "*", nil),
-			tokenizer(token.Slash, "/", nil),
-			tokenizer(token.Percent, "%", nil),
-
-			tokenizer(token.LogicalAnd, "&&", nil),
-			tokenizer(token.LogicalOr, "||", nil),
-
-			tokenizer(token.AndNot, "&^", nil),
-			tokenizer(token.Ampersand, "&", nil),
-			tokenizer(token.Pipe, "|", nil),
-			tokenizer(token.Caret, "^", nil),
-
-			tokenizer(token.ShiftLeft, "<<", nil),
-			tokenizer(token.ShiftRight, ">>", nil),
-
-			tokenizer(token.Equal, "==", nil),
-			tokenizer(token.NotEqual, "!=", nil),
-			tokenizer(token.LessOrEqual, "<=", nil),
-			tokenizer(token.GreaterOrEqual, ">=", nil),
-			tokenizer(token.Less, "<", nil),
-			tokenizer(token.Greater, ">", nil),
-
-			tokenizer(token.Exclamation, "!", nil),
-
-			tokenizer(token.Assign, "=", nil),
-			tokenizer(token.Define, ":=", nil),
-
-			tokenizer(token.Comma, ",", nil),
-			tokenizer(token.Period, ".", nil),
-			tokenizer(token.Semicolon, ";", nil),
-			tokenizer(token.Colons, "::", nil),
-			tokenizer(token.Colon, ":", nil),
-			tokenizer(token.Hash, "#", nil),
-
-			tokenizer(token.ParenLeft, "(", nil),
-			tokenizer(token.BracketLeft, "[", nil),
-			tokenizer(token.BraceLeft, "{", nil),
-
-			tokenizer(token.ParenRight, ")", nil),
-			tokenizer(token.BracketRight, "]", nil),
-			tokenizer(token.BraceRight, "}", nil),
-		} {
-			if pan.Recover(func() {
-				after, t := f(s)
-				tokens = append(tokens, t)
-				s = after
-			}) == nil {
-				continue next
+	return FileMode(pos, text, 0)
+}
+
+// FileMode is like File, but with Scanner mode flags.  In AllErrors mode
+// tokens are returned also when there are lexical errors; the error is a
+// source.ErrorList.
+func FileMode(pos source.Position, text string, mode Mode) ([]token.Token, error) {
+	var (
+		s      = NewStringScanner(pos, text)
+		tokens []token.Token
+	)
+
+	s.Mode = mode
+
+	for {
+		t, err := s.Next()
+		if err != nil {
+			if err == io.EOF {
+				return tokens, s.Errors().Err()
 			}
+			return nil, err
 		}
 
-		pan.Panic(tokenError(s))
+		tokens = append(tokens, t)
+	}
+}
+
+// skipBOM skips byte order mark.  It doesn't occupy a column.
+func skipBOM(s scan) scan {
+	if s.hasPrefix(bom) {
+		s.ByteOffset += len(bom)
 	}
+	return s
+}
 
-	return
+// tokenizeShebang consumes an interpreter directive line as a comment.
+func tokenizeShebang(s scan) (scan, token.Token) {
+	end, ok := scanComment(s)
+	if !ok {
+		pan.Panic(tokenError(s))
+	}
+	return makeToken(token.Comment, s, end)
 }
 
-func tokenizeSpace(s scan) (scan, token.Token) {
+// tokenizeNext dispatches on the first rune, which must be valid.
+func tokenizeNext(s scan) (scan, token.Token) {
 	start := s
 
-	for {
-		if c := s.peek(); c == '\n' || !unicode.IsSpace(c) {
-			return makeToken(token.Space, start, s)
-		}
+	switch c := s.peek(); {
+	case c == '\n':
 		s.advance()
+		return makeToken(token.Newline, start, s)
+
+	case c == '\r' && s.hasPrefix("\r\n"):
+		s.advanceASCII(1)
+		s.advance()
+		return makeToken(token.Newline, start, s)
+
+	case c == '/' && s.hasPrefix("/*"):
+		return tokenizeBlockComment(s)
+
+	case c == '/' && s.hasPrefix("//"):
+		if end, ok := scanComment(s); ok {
+			kind := token.Comment
+			if s.hasPrefix("///") && !s.hasPrefix("////") {
+				kind = token.DocComment
+			}
+			return makeToken(kind, start, end)
+		}
+		fallthrough // Slash operator if comment is not encoded correctly.
+
+	case c < utf8.RuneSelf && operators[c] != nil:
+		for _, op := range operators[c] {
+			if s.hasPrefix(op.source) {
+				s.advanceASCII(len(op.source))
+				return makeToken(op.kind, start, s)
+			}
+		}
+
+	case c == '\'':
+		return tokenizeQuoted(s, token.Character, c)
+
+	case c == '"' || c == '`':
+		return tokenizeQuoted(s, token.String, c)
+
+	case wordStartRune(c):
+		return tokenizeWord(s)
+
+	case isDigit(c, 10):
+		return tokenizeNumber(s)
+
+	case unicode.IsSpace(c):
+		return tokenizeSpace(s)
 	}
+
+	panic(pan.Wrap(tokenError(s)))
 }
 
-func tokenizeComment(s scan) (scan, token.Token) {
+func tokenizeSpace(s scan) (scan, token.Token) {
 	start := s
 
-	for range 2 {
-		if s.peek() != '/' {
-			pan.Panic(tokenError(start))
+	for {
+		if c := s.peekWithin(start); c == '\n' || !unicode.IsSpace(c) || (c == '\r' && s.hasPrefix("\r\n")) {
+			return makeToken(token.Space, start, s)
 		}
 		s.advance()
 	}
+}
 
+func scanComment(s scan) (scan, bool) {
 	for {
 		switch s.peek() {
 		case '\n', 0:
-			return makeToken(token.Comment, start, s)
+			return s, true
+		case '\r':
+			if s.hasPrefix("\r\n") {
+				return s, true
+			}
+		case invalid:
+			return s, false
 		}
 		s.advance()
 	}
 }
 
-func wordTokenizer(k token.Kind, upper bool) func(scan) (scan, token.Token) {
-	return func(s scan) (scan, token.Token) {
-		start := s
+// tokenizeBlockComment consumes a block comment.  Block comments nest.  An
+// unterminated comment spans the rest of the input.
+func tokenizeBlockComment(s scan) (scan, token.Token) {
+	start := s
+	s.advanceASCII(2)
 
-		if r := s.peek(); !wordStartRune(r) || unicode.IsUpper(r) != upper {
-			pan.Panic(tokenError(start))
-		}
+	for depth := 1; depth > 0; {
+		switch {
+		case s.hasPrefix("*/"):
+			s.advanceASCII(2)
+			depth--
 
-		for {
-			s.advance()
+		case s.hasPrefix("/*"):
+			s.advanceASCII(2)
+			depth++
 
-			if !wordRune(s.peek()) {
-				return makeToken(k, start, s)
+		default:
+			if s.peekWithin(start) == 0 {
+				pan.Panic(spanError{commentError(start), s})
 			}
+			s.advance()
 		}
 	}
+
+	return makeToken(token.BlockComment, start, s)
 }
 
-func tokenizeInteger(s scan) (scan, token.Token) {
+func tokenizeWord(s scan) (scan, token.Token) {
 	start := s
+	upper := unicode.IsUpper(s.peek())
 
 	for {
-		if !unicode.IsDigit(s.peek()) {
-			return makeToken(token.Integer, start, s)
-		}
 		s.advance()
+
+		if !wordRune(s.peekWithin(start)) {
+			break
+		}
+	}
+
+	if upper {
+		return makeToken(token.WordUpper, start, s)
 	}
+
+	if k, ok := keywords[start.until(s.ByteOffset)]; ok {
+		return makeToken(k, start, s)
+	}
+
+	return makeToken(token.WordLower, start, s)
 }
 
-func quoteTokenizer(k token.Kind, quote rune) func(scan) (scan, token.Token) {
-	return func(s scan) (scan, token.Token) {
-		start := s
+func tokenizeNumber(s scan) (scan, token.Token) {
+	start := s
+	kind := token.Integer
+	base := 10
+
+	if s.peek() == '0' {
+		prefix := s
+		prefix.advance()
+
+		switch unicode.ToLower(prefix.peekWithin(start)) {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
 
-		if s.peek() != quote {
-			pan.Panic(tokenError(start))
+		if base != 10 {
+			s = prefix
+			s.advance()
 		}
-		s.advance()
+	}
 
-		for {
-			c := s.peek()
-			if c == 0 {
-				pan.Panic(tokenError(start))
+	digits := scanDigits(&s, start, base, base != 10)
+
+	if base == 10 || base == 16 {
+		if s.peek() == '.' {
+			fraction := s
+			fraction.advance()
+
+			if isDigit(fraction.peekWithin(start), base) {
+				s = fraction
+				kind = token.Float
+				digits += scanDigits(&s, start, base, false)
 			}
+		}
 
+		if c := unicode.ToLower(s.peekWithin(start)); (base == 10 && c == 'e') || (base == 16 && c == 'p') {
 			s.advance()
+			kind = token.Float
 
-			switch c {
-			case '\\':
-				if s.peek() == 0 {
-					pan.Panic(tokenError(start))
-				}
+			if c := s.peekWithin(start); c == '+' || c == '-' {
 				s.advance()
+			}
 
-			case quote:
-				return makeToken(k, start, s)
+			if scanDigits(&s, start, 10, false) == 0 {
+				pan.Panic(numberError(s, "exponent has no digits"))
 			}
+		} else if base == 16 && kind == token.Float {
+			pan.Panic(numberError(s, "hexadecimal mantissa requires p exponent"))
 		}
 	}
+
+	if c := s.peekWithin(start); isDigit(c, 10) {
+		pan.Panic(numberError(s, "invalid digit %q in %s literal", c, baseNames[base]))
+	} else if wordRune(c) {
+		pan.Panic(numberError(s, "invalid character %q in %s literal", c, baseNames[base]))
+	}
+
+	if digits == 0 {
+		pan.Panic(numberError(s, "%s literal has no digits", baseNames[base]))
+	}
+
+	return makeToken(kind, start, s)
 }
 
-func tokenizer(k token.Kind, source string, failIfTrailing func(rune) bool) func(scan) (scan, token.Token) {
-	return func(s scan) (scan, token.Token) {
-		start := s
+var baseNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	10: "decimal",
+	16: "hexadecimal",
+}
 
-		for _, wanted := range source {
-			switch s.peek() {
-			case 0:
-				pan.Panic(tokenError(start))
+// scanDigits returns the number of digits.  Leading separator is permitted
+// after base prefix.
+func scanDigits(s *scan, start scan, base int, prefixed bool) (digits int) {
+	separator := false
 
-			case wanted:
-				s.advance()
+	for {
+		c := s.peekWithin(start)
 
-			default:
-				pan.Panic(tokenError(start))
+		switch {
+		case c == '_':
+			if separator || (digits == 0 && !prefixed) {
+				pan.Panic(numberError(*s, "'_' must separate successive digits"))
+			}
+			separator = true
+
+		case isDigit(c, base):
+			separator = false
+			digits++
+
+		default:
+			if separator {
+				pan.Panic(numberError(*s, "'_' must separate successive digits"))
 			}
+			return
 		}
 
-		if failIfTrailing != nil && failIfTrailing(s.peek()) {
+		s.advance()
+	}
+}
+
+func tokenizeQuoted(s scan, k token.Kind, quote rune) (scan, token.Token) {
+	start := s
+	s.advance()
+
+	for {
+		c := s.peekWithin(start)
+		if c == 0 {
 			pan.Panic(tokenError(start))
 		}
 
-		return makeToken(k, start, s)
+		s.advance()
+
+		switch c {
+		case '\\':
+			if s.peekWithin(start) == 0 {
+				pan.Panic(tokenError(start))
+			}
+			s.advance()
+
+		case quote:
+			checkQuoted(start, s, k)
+			return makeToken(k, start, s)
+		}
+	}
+}
+
+// checkQuoted validates escape sequences and character count.
+func checkQuoted(start, end scan, k token.Kind) {
+	source := start.until(end.ByteOffset)
+
+	var err error
+	if k == token.Character {
+		_, err = literal.UnquoteChar(source)
+	} else {
+		_, err = literal.Unquote(source)
+	}
+
+	if e, ok := err.(*literal.Error); ok {
+		pan.Panic(spanError{literalError(start, source, e), end})
+	}
+}
+
+// skipIllegal returns the end of the invalid input which starts at s.  Invalid
+// words and numbers are skipped as a whole.  Otherwise the span extends over
+// runes which can't start a token.
+func skipIllegal(s scan) scan {
+	var (
+		c      = s.peek()
+		number = isDigit(c, 10)
+		word   = wordRune(c)
+	)
+
+	for first := true; ; first = false {
+		switch c = s.peek(); {
+		case c == 0:
+			return s
+
+		case c == invalid:
+			s.skipByte()
+
+		case first, word && wordRune(c), number && c == '.', !word && !tokenStartRune(c):
+			s.advance()
+
+		default:
+			return s
+		}
 	}
 }
 
@@ -234,6 +435,29 @@ func makeToken(k token.Kind, start, end scan) (scan, token.Token) {
 	return end, token.Token{start.pos(), k, source}
 }
 
+func isDigit(r rune, base int) bool {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r-'0') < base
+	case base == 16:
+		r |= 0x20 // Lower case.
+		return r >= 'a' && r <= 'f'
+	default:
+		return false
+	}
+}
+
+func tokenStartRune(c rune) bool {
+	switch {
+	case c < utf8.RuneSelf && operators[c] != nil:
+		return true
+	case c == '\'' || c == '"' || c == '`':
+		return true
+	default:
+		return wordStartRune(c) || isDigit(c, 10) || unicode.IsSpace(c)
+	}
+}
+
 func wordStartRune(r rune) bool {
 	return r == '_' || unicode.IsLetter(r)
 }
@@ -0,0 +1,309 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package lex
+
+// The original lexer implementation which tries every tokenizer in turn.  It
+// is kept for comparison.
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
+)
+
+type trialScan struct {
+	source.Position
+	text string
+}
+
+func (s trialScan) pos() source.Position {
+	return s.Position
+}
+
+func (s *trialScan) peek() rune {
+	r, _ := s.peekSize()
+	return r
+}
+
+func (s *trialScan) advance() {
+	c, n := s.peekSize()
+	if c == 0 {
+		panic("advanced on EOF")
+	}
+
+	if c == '\n' {
+		s.Line++
+		s.Column = 1
+	} else {
+		s.Column++
+	}
+	s.ByteOffset += n
+}
+
+func (s *trialScan) peekSize() (rune, int) {
+	if s.ByteOffset == len(s.text) {
+		return 0, 0
+	}
+
+	c, n := utf8.DecodeRuneInString(s.text[s.ByteOffset:])
+	if c == utf8.RuneError {
+		pan.Panic(decodeError(s.pos()))
+	}
+	if c == 0 {
+		return utf8.RuneError, n
+	}
+
+	return c, n
+}
+
+func (s *trialScan) until(endByteOffset int) string {
+	return s.text[s.ByteOffset:endByteOffset]
+}
+
+func trialTokenError(s trialScan) error {
+	return tokenError(scan{Position: s.Position})
+}
+
+func trialFile(pos source.Position, text string) ([]token.Token, error) {
+	var tokens []token.Token
+
+	err := pan.Recover(func() {
+		tokens = trialTokenize(trialScan{pos, text})
+	})
+
+	return tokens, err
+}
+
+func trialTokenize(s trialScan) (tokens []token.Token) {
+next:
+	for s.peek() != 0 {
+		for _, f := range []func(trialScan) (trialScan, token.Token){
+			trialTokenizeSpace,
+			trialTokenizer(token.Newline, "\n", nil),
+			trialTokenizeComment,
+
+			trialTokenizer(token.Auto, "auto", wordRune),
+			trialTokenizer(token.Break, "break", wordRune),
+			trialTokenizer(token.Case, "case", wordRune),
+			trialTokenizer(token.Clone, "clone", wordRune),
+			trialTokenizer(token.Continue, "continue", wordRune),
+			trialTokenizer(token.Default, "default", wordRune),
+			trialTokenizer(token.Defer, "defer", wordRune),
+			trialTokenizer(token.Else, "else", wordRune),
+			trialTokenizer(token.False, "false", wordRune),
+			trialTokenizer(token.For, "for", wordRune),
+			trialTokenizer(token.Func, "func", wordRune),
+			trialTokenizer(token.If, "if", wordRune),
+			trialTokenizer(token.Import, "import", wordRune),
+			trialTokenizer(token.Nil, "nil", wordRune),
+			trialTokenizer(token.Range, "range", wordRune),
+			trialTokenizer(token.Return, "return", wordRune),
+			trialTokenizer(token.Switch, "switch", wordRune),
+			trialTokenizer(token.True, "true", wordRune),
+
+			trialWordTokenizer(token.WordLower, false),
+			trialWordTokenizer(token.WordUpper, true),
+
+			trialTokenizeInteger,
+			trialQuoteTokenizer(token.Character, '\''),
+			trialQuoteTokenizer(token.String, '"'),
+			trialQuoteTokenizer(token.String, '`'),
+
+			trialTokenizer(token.PlusAssign, "+=", nil),
+			trialTokenizer(token.MinusAssign, "-=", nil),
+			trialTokenizer(token.AsteriskAssign, "*=", nil),
+			trialTokenizer(token.SlashAssign, "/=", nil),
+			trialTokenizer(token.PercentAssign, "%=", nil),
+			trialTokenizer(token.AndNotAssign, "&^=", nil),
+			trialTokenizer(token.AmpersandAssign, "&=", nil),
+			trialTokenizer(token.PipeAssign, "|=", nil),
+			trialTokenizer(token.CaretAssign, "^=", nil),
+			trialTokenizer(token.ShiftLeftAssign, "<<=", nil),
+			trialTokenizer(token.ShiftRightAssign, ">>=", nil),
+
+			trialTokenizer(token.Plus, "+", nil),
+			trialTokenizer(token.Minus, "-", nil),
+			trialTokenizer(token.Asterisk, "*", nil),
+			trialTokenizer(token.Slash, "/", nil),
+			trialTokenizer(token.Percent, "%", nil),
+
+			trialTokenizer(token.LogicalAnd, "&&", nil),
+			trialTokenizer(token.LogicalOr, "||", nil),
+
+			trialTokenizer(token.AndNot, "&^", nil),
+			trialTokenizer(token.Ampersand, "&", nil),
+			trialTokenizer(token.Pipe, "|", nil),
+			trialTokenizer(token.Caret, "^", nil),
+
+			trialTokenizer(token.ShiftLeft, "<<", nil),
+			trialTokenizer(token.ShiftRight, ">>", nil),
+
+			trialTokenizer(token.Equal, "==", nil),
+			trialTokenizer(token.NotEqual, "!=", nil),
+			trialTokenizer(token.LessOrEqual, "<=", nil),
+			trialTokenizer(token.GreaterOrEqual, ">=", nil),
+			trialTokenizer(token.Less, "<", nil),
+			trialTokenizer(token.Greater, ">", nil),
+
+			trialTokenizer(token.Exclamation, "!", nil),
+
+			trialTokenizer(token.Assign, "=", nil),
+			trialTokenizer(token.Define, ":=", nil),
+
+			trialTokenizer(token.Comma, ",", nil),
+			trialTokenizer(token.Period, ".", nil),
+			trialTokenizer(token.Semicolon, ";", nil),
+			trialTokenizer(token.Colons, "::", nil),
+			trialTokenizer(token.Colon, ":", nil),
+			trialTokenizer(token.Hash, "#", nil),
+
+			trialTokenizer(token.ParenLeft, "(", nil),
+			trialTokenizer(token.BracketLeft, "[", nil),
+			trialTokenizer(token.BraceLeft, "{", nil),
+
+			trialTokenizer(token.ParenRight, ")", nil),
+			trialTokenizer(token.BracketRight, "]", nil),
+			trialTokenizer(token.BraceRight, "}", nil),
+		} {
+			if pan.Recover(func() {
+				after, t := f(s)
+				tokens = append(tokens, t)
+				s = after
+			}) == nil {
+				continue next
+			}
+		}
+
+		pan.Panic(trialTokenError(s))
+	}
+
+	return
+}
+
+func trialTokenizeSpace(s trialScan) (trialScan, token.Token) {
+	start := s
+
+	for {
+		if c := s.peek(); c == '\n' || !unicode.IsSpace(c) {
+			return trialMakeToken(token.Space, start, s)
+		}
+		s.advance()
+	}
+}
+
+func trialTokenizeComment(s trialScan) (trialScan, token.Token) {
+	start := s
+
+	for range 2 {
+		if s.peek() != '/' {
+			pan.Panic(trialTokenError(start))
+		}
+		s.advance()
+	}
+
+	for {
+		switch s.peek() {
+		case '\n', 0:
+			return trialMakeToken(token.Comment, start, s)
+		}
+		s.advance()
+	}
+}
+
+func trialWordTokenizer(k token.Kind, upper bool) func(trialScan) (trialScan, token.Token) {
+	return func(s trialScan) (trialScan, token.Token) {
+		start := s
+
+		if r := s.peek(); !wordStartRune(r) || unicode.IsUpper(r) != upper {
+			pan.Panic(trialTokenError(start))
+		}
+
+		for {
+			s.advance()
+
+			if !wordRune(s.peek()) {
+				return trialMakeToken(k, start, s)
+			}
+		}
+	}
+}
+
+func trialTokenizeInteger(s trialScan) (trialScan, token.Token) {
+	start := s
+
+	for {
+		if !unicode.IsDigit(s.peek()) {
+			return trialMakeToken(token.Integer, start, s)
+		}
+		s.advance()
+	}
+}
+
+func trialQuoteTokenizer(k token.Kind, quote rune) func(trialScan) (trialScan, token.Token) {
+	return func(s trialScan) (trialScan, token.Token) {
+		start := s
+
+		if s.peek() != quote {
+			pan.Panic(trialTokenError(start))
+		}
+		s.advance()
+
+		for {
+			c := s.peek()
+			if c == 0 {
+				pan.Panic(trialTokenError(start))
+			}
+
+			s.advance()
+
+			switch c {
+			case '\\':
+				if s.peek() == 0 {
+					pan.Panic(trialTokenError(start))
+				}
+				s.advance()
+
+			case quote:
+				return trialMakeToken(k, start, s)
+			}
+		}
+	}
+}
+
+func trialTokenizer(k token.Kind, source string, failIfTrailing func(rune) bool) func(trialScan) (trialScan, token.Token) {
+	return func(s trialScan) (trialScan, token.Token) {
+		start := s
+
+		for _, wanted := range source {
+			switch s.peek() {
+			case 0:
+				pan.Panic(trialTokenError(start))
+
+			case wanted:
+				s.advance()
+
+			default:
+				pan.Panic(trialTokenError(start))
+			}
+		}
+
+		if failIfTrailing != nil && failIfTrailing(s.peek()) {
+			pan.Panic(trialTokenError(start))
+		}
+
+		return trialMakeToken(k, start, s)
+	}
+}
+
+func trialMakeToken(k token.Kind, start, end trialScan) (trialScan, token.Token) {
+	source := start.until(end.ByteOffset)
+	if source == "" {
+		pan.Panic(trialTokenError(start))
+	}
+
+	return end, token.Token{start.pos(), k, source}
+}
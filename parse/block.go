@@ -10,132 +10,574 @@ import (
 )
 
 func parseStatements(s scan) (scan, []ast.BlockChild) {
-	return parseListUntil(s, skipper(token.BraceRight),
-		parseAssign,
-		parseBlock,
-		parseBreak,
-		parseCommentInBlock,
-		parseContinue,
-		parseExpressionInBlock,
-		parseFor,
-		parseIf,
-		parseImportInBlock,
-		parseNewlineInBlock,
-		parseReturn,
-		parseSemicolonInBlock,
-		parseVariableDecl,
-		parseVariableDef,
-	)
+	return parseListRecover(s, skipper(token.BraceRight), false, badStmt, parseStatement)
+}
+
+func parseStatement(s scan) (scan, ast.BlockChild) {
+	switch k := s.peek().Kind; {
+	case k == 0:
+		unexpected(s, "closing brace")
+
+	case k == token.BraceLeft:
+		return parseBlock(s)
+
+	case k == token.Break:
+		return parseBreak(s)
+
+	case isComment(k):
+		return parseComment(s)
+
+	case k == token.Continue:
+		return parseContinue(s)
+
+	case k == token.Defer:
+		return parseDefer(s)
+
+	case k == token.For:
+		return parseFor(s)
+
+	case k == token.If:
+		return parseIf(s)
+
+	case k == token.Import:
+		return parseImport(s, true)
+
+	case k == token.Newline:
+		return parseNewline(s), nil
+
+	case k == token.Return:
+		return parseReturn(s)
+
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+
+	case k == token.Switch:
+		return parseSwitch(s)
+
+	case k == token.WordLower && isLoopLabel(s):
+		return parseFor(s)
+
+	case k == token.Comma, k == token.WordLower:
+		switch peekAfterNames(s).Kind {
+		case token.Colon:
+			return parseVariableDecl(s)
+		case token.Define:
+			return parseVariableDef(s)
+		}
+	}
+
+	if hasAssignOperator(s) {
+		return parseAssign(s)
+	}
+	return parseExpressionStatement(s)
+}
+
+// peekAfterNames returns the token after a list of variable names.
+func peekAfterNames(s scan) token.Token {
+	s = skipNames(s)
+	return s.peek()
+}
+
+// skipNames skips a list of variable names.
+func skipNames(s scan) scan {
+	for {
+		switch k := s.peek().Kind; k {
+		case token.Comma, token.WordLower:
+			s.skip(k)
+		default:
+			return s
+		}
+	}
+}
+
+// hasAssignOperator looks for assignment operator outside of brackets before
+// end of statement.
+func hasAssignOperator(s scan) bool {
+	var depth int
+
+	for {
+		t := s.peek()
+
+		switch k := t.Kind; {
+		case k == 0, k == token.Newline, k == token.Semicolon, isComment(k):
+			return false
+
+		case isAssignOperator(k):
+			if depth == 0 {
+				return true
+			}
+
+		case k == token.BraceLeft, k == token.BracketLeft, k == token.ParenLeft:
+			depth++
+
+		case k == token.BraceRight, k == token.BracketRight, k == token.ParenRight:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		}
+
+		s.skip(t.Kind)
+	}
 }
 
 func parseAssign(s scan) (scan, ast.BlockChild) {
-	s, names := parseNakedList(s, token.Assign,
-		parseAssignerDereferenceInAssignList,
-		parseCallInAssignList,
-		parseCastInAssignList,
-		parseCommaInAssignList,
-		parseIndexInAssignList,
-		parseSelectorInAssignList,
-	)
-	if len(names) == 0 {
-		pan.Panic(newError(s.pos(), "assign: empty list"))
+	return parseAssignValues(s, parseExprList)
+}
+
+// parseAssignValues parses assignment using the given value list parser.
+func parseAssignValues(s scan, parseValues func(scan) (scan, []ast.ExprListChild)) (scan, ast.BlockChild) {
+	pos := s.pos()
+
+	s, targets := parseAssignTargets(s)
+	if len(targets) == 0 {
+		pan.Panic(newError(pos, "expected assignment target, found "+describe(s.peek())))
 	}
 
-	s.take(token.Assign, "assign: operator expected")
+	t := s.peek()
+	op, ok := assignOperator(t.Kind)
+	if !ok {
+		unexpected(s, "assignment operator")
+	}
+	s.skip(t.Kind)
 
-	s, values := parseExprList(s)
+	s, values := parseValues(s)
 	if len(values) == 0 {
-		pan.Panic(newError(s.pos(), "assign: empty list"))
+		unexpected(s, "value")
+	}
+
+	if op != 0 && (len(targets) != 1 || countExprs(values) != 1) {
+		pan.Panic(newError(t.Pos(), "compound assignment needs single target and value"))
+	}
+
+	return s, ast.Assign{targets[0].Pos(), targets, op, values, s.last}
+}
+
+// parseAssignTargets parses items until assignment operator or end of
+// statement.
+func parseAssignTargets(s scan) (scan, []ast.AssignListChild) {
+	var targets []ast.AssignListChild
+
+	for {
+		switch k := s.peek().Kind; {
+		case k == 0, k == token.Newline, k == token.Semicolon, isComment(k), isAssignOperator(k):
+			return s, targets
+		}
+
+		var node ast.AssignListChild
+		s, node = parseAssignListChild(s)
+		if node != nil {
+			targets = append(targets, node)
+		}
+	}
+}
+
+func parseAssignListChild(s scan) (scan, ast.AssignListChild) {
+	switch s.peek().Kind {
+	case token.Comma:
+		return parseComma(s), nil
+
+	case token.ParenLeft:
+		s, node, ok := parseAssignerDereference(s)
+		if !ok {
+			unexpected(s, "assigner name in parentheses")
+		}
+		return s, node
+
+	case token.WordUpper:
+		s, cast := parseCast(s)
+		return parseAssignPostfix(s, cast)
+
+	case token.WordLower:
+		s, name := parseSelectorOnly(s)
+		return parseAssignPostfix(s, name)
 	}
 
-	return s, ast.Assign{names[0].Pos(), names, values, s.last}
+	unexpected(s, "assignment target")
+	return s, nil
+}
+
+// parseAssignPostfix parses postfix chain of assignment target.  The result is
+// the operand itself, or Call, FieldSelect or Index.
+func parseAssignPostfix(s scan, operand ast.ExprChild) (scan, ast.AssignListChild) {
+	s, expr := parsePostfix(s, operand)
+
+	target, ok := expr.(ast.AssignListChild)
+	if !ok {
+		pan.Panic(newError(expr.Pos(), "slice expression cannot be assigned"))
+	}
+	return s, target
 }
 
 func parseBlock(s scan) (scan, ast.BlockChild) {
-	t := s.take(token.BraceLeft, "block: opening brace expected")
+	t := s.take(token.BraceLeft, "opening brace")
 	s, body := parseStatements(s)
 	return s, ast.Block{t.Pos(), body, s.last}
 }
 
 func parseBreak(s scan) (scan, ast.BlockChild) {
-	t := s.take(token.Break, "break keyword expected")
-	return s, ast.Break{t.Pos(), s.last}
+	t := s.take(token.Break, "break keyword")
+	label, _ := s.skim(token.WordLower)
+	return s, ast.Break{t.Pos(), label.Source, s.last}
 }
 
 func parseContinue(s scan) (scan, ast.BlockChild) {
-	t := s.take(token.Continue, "continue keyword expected")
-	return s, ast.Continue{t.Pos(), s.last}
+	t := s.take(token.Continue, "continue keyword")
+	label, _ := s.skim(token.WordLower)
+	return s, ast.Continue{t.Pos(), label.Source, s.last}
+}
+
+// parseDefer accepts any expression; it's up to a later pass to check that it
+// is a call.
+func parseDefer(s scan) (scan, ast.BlockChild) {
+	t := s.take(token.Defer, "defer keyword")
+	s, expr := parseAnyExpr(s, false)
+
+	switch k := s.peek().Kind; {
+	case k == token.Newline, k == token.Semicolon, isComment(k):
+	default:
+		unexpected(s, "end of statement")
+	}
+
+	return s, ast.Defer{t.Pos(), expr, s.last}
+}
+
+func parseExpressionStatement(s scan) (scan, ast.BlockChild) {
+	s, expr := parseAnyExpr(s, false)
+
+	switch k := s.peek().Kind; {
+	case k == token.Newline, k == token.Semicolon, isComment(k):
+	default:
+		unexpected(s, "end of statement")
+	}
+
+	return s, ast.Expression{expr}
+}
+
+// isLoopLabel tells if the next tokens are a name and a colon followed by a
+// for keyword (possibly on a later line).
+func isLoopLabel(s scan) bool {
+	if !s.skip(token.WordLower) || !s.skip(token.Colon) {
+		return false
+	}
+
+	skipNewlines(&s)
+	return s.peek().Kind == token.For
 }
 
 func parseFor(s scan) (scan, ast.BlockChild) {
-	keyword := s.take(token.For, "for keyword expected")
+	pos := s.pos()
 
-	var test ast.ExprChild
+	var label string
+	if t, ok := s.skim(token.WordLower); ok {
+		label = t.Source
+		s.take(token.Colon, "colon after label")
+		skipNewlines(&s)
+	}
 
-	open, ok := s.skim(token.BraceLeft)
-	if !ok {
+	s.take(token.For, "for keyword")
+	s.header = true
+
+	var (
+		init ast.BlockChild
+		test ast.ExprChild
+		post ast.BlockChild
+		loop *ast.ForRange
+	)
+
+	switch k := s.peek().Kind; {
+	case k == token.BraceLeft:
+
+	case isForRange(s):
+		s, loop = parseForRange(s)
+
+	case hasForClauses(s):
+		if s.peek().Kind != token.Semicolon {
+			s, init = parseSimpleStatement(s)
+		}
+		s.take(token.Semicolon, "semicolon after loop initialization")
+
+		if s.peek().Kind != token.Semicolon {
+			s, test = parseAnyExpr(s, false)
+		}
+		s.take(token.Semicolon, "semicolon after loop condition")
+
+		if s.peek().Kind != token.BraceLeft {
+			s, post = parsePostStatement(s)
+		}
+
+	default:
 		s, test = parseAnyExpr(s, false)
-		open = s.take(token.BraceLeft, "for: opening brace expected")
+
+		if t := s.peek(); t.Kind == token.Define || isAssignOperator(t.Kind) {
+			pan.Panic(newError(t.Pos(), "loop initialization must be followed by semicolon"))
+		}
 	}
 
+	s.header = false
+	open := s.take(token.BraceLeft, "opening brace of loop body")
+
 	s, body := parseStatements(s)
-	return s, ast.For{keyword.Pos(), test, open.Pos(), body, s.last}
+	return s, ast.For{pos, label, init, test, post, loop, open.Pos(), body, s.last}
+}
+
+// isForRange tells if loop header starts with variable names followed by
+// define operator and range keyword.
+func isForRange(s scan) bool {
+	if k := s.peek().Kind; k != token.Comma && k != token.WordLower {
+		return false
+	}
+
+	s = skipNames(s)
+	return s.skip(token.Define) && s.peek().Kind == token.Range
+}
+
+// hasForClauses looks for semicolon outside of brackets before the opening
+// brace of loop body.
+func hasForClauses(s scan) bool {
+	var depth int
+
+	for {
+		t := s.peek()
+
+		switch t.Kind {
+		case 0, token.Newline:
+			return false
+
+		case token.Semicolon:
+			if depth == 0 {
+				return true
+			}
+
+		case token.BraceLeft:
+			if depth == 0 {
+				return false
+			}
+			depth++
+
+		case token.BracketLeft, token.ParenLeft:
+			depth++
+
+		case token.BraceRight, token.BracketRight, token.ParenRight:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		}
+
+		s.skip(t.Kind)
+	}
+}
+
+func parseForRange(s scan) (scan, *ast.ForRange) {
+	pos := s.pos()
+
+	s, names := parseNakedList(s, 0, parseVariableName)
+	if len(names) == 0 || len(names) > 2 {
+		pan.Panic(newError(pos, "range loop needs index variable and optional item variable"))
+	}
+
+	s.take(token.Define, "define operator")
+	s.take(token.Range, "range keyword")
+
+	s, expr := parseAnyExpr(s, false)
+
+	return s, &ast.ForRange{pos, names, expr, s.last}
+}
+
+// parseSimpleStatement parses loop initialization.
+func parseSimpleStatement(s scan) (scan, ast.BlockChild) {
+	switch k := s.peek().Kind; {
+	case (k == token.Comma || k == token.WordLower) && peekAfterNames(s).Kind == token.Define:
+		return parseVariableDef(s)
+
+	case hasAssignOperator(s):
+		return parseAssign(s)
+	}
+
+	s, expr := parseAnyExpr(s, false)
+	return s, ast.Expression{expr}
+}
+
+// parsePostStatement parses loop post statement.  It's terminated by the
+// opening brace of loop body.
+func parsePostStatement(s scan) (scan, ast.BlockChild) {
+	if hasAssignOperator(s) {
+		return parseAssignValues(s, func(s scan) (scan, []ast.ExprListChild) {
+			return parseNakedList(s, token.BraceLeft, parsePostValue)
+		})
+	}
+
+	s, expr := parseAnyExpr(s, false)
+	return s, ast.Expression{expr}
+}
+
+func parsePostValue(s scan) (scan, ast.ExprListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+
+	s, expr := parseAnyExpr(s, false)
+
+	if !s.skip(token.Comma) && s.peek().Kind != token.BraceLeft {
+		unexpected(s, "comma or opening brace of loop body")
+	}
+
+	return s, ast.Expression{expr}
 }
 
 func parseIf(s scan) (scan, ast.BlockChild) {
-	keyword := s.take(token.If, "if keyword expected")
+	keyword := s.take(token.If, "if keyword")
+	s.header = true
 	s, test := parseAnyExpr(s, false)
+	s.header = false
 
-	thenAt := s.take(token.BraceLeft, "if: opening brace expected").Pos()
+	thenAt := s.take(token.BraceLeft, "opening brace of if body").Pos()
 	s, then := parseStatements(s)
 	thenEnd := s.last
 
-	var els []ast.BlockChild
+	var (
+		elseIf bool
+		els    []ast.BlockChild
+	)
 	if s.skip(token.Else) {
-		s.take(token.BraceLeft, "else: opening brace expected")
-		s, els = parseStatements(s)
+		if s.peek().Kind == token.If {
+			var chained ast.BlockChild
+			s, chained = parseIf(s)
+			elseIf = true
+			els = []ast.BlockChild{chained}
+		} else {
+			s.take(token.BraceLeft, "opening brace of else body or if keyword")
+			s, els = parseStatements(s)
+		}
 	}
 
-	return s, ast.If{keyword.Pos(), test, thenAt, then, thenEnd, els, s.last}
+	return s, ast.If{keyword.Pos(), test, thenAt, then, thenEnd, elseIf, els, s.last}
 }
 
 func parseReturn(s scan) (scan, ast.BlockChild) {
-	keyword := s.take(token.Return, "return keyword expected")
-
-	s, values := parse(s,
-		func(s scan) (scan, []ast.ExprListChild) {
-			return parseNakedList(s, 0,
-				parseCommaInExprList,
-				parseExpressionInExprList,
-			)
-		},
-
-		func(s scan) (scan, []ast.ExprListChild) {
-			s.take(token.ParenLeft, "return value list expected")
-			return parseListUntil(s, skipper(token.ParenRight),
-				parseCommaInExprList,
-				parseCommentInExprList,
-				parseExpressionInExprList,
-				parseNewlineInExprList,
-			)
-		},
-	)
+	keyword := s.take(token.Return, "return keyword")
+
+	var values []ast.ExprListChild
+	if s.peek().Kind == token.ParenLeft && isParenthesizedList(s) {
+		s.skip(token.ParenLeft)
+		s, values = parseListUntil(s, skipper(token.ParenRight), parseExprListChild)
+	} else {
+		s, values = parseNakedList(s, 0, parseExprListChildNaked)
+	}
 
 	return s, ast.Return{keyword.Pos(), values, s.last}
 }
 
+// isParenthesizedList tells if the parenthesis at s encloses an expression
+// list instead of a single expression: it's empty, or contains commas or
+// comments.
+func isParenthesizedList(s scan) bool {
+	var (
+		depth int
+		empty = true
+	)
+
+	for {
+		t := s.peek()
+
+		switch k := t.Kind; {
+		case k == 0:
+			return false
+
+		case k == token.BraceLeft, k == token.BracketLeft, k == token.ParenLeft:
+			depth++
+
+		case k == token.BraceRight, k == token.BracketRight, k == token.ParenRight:
+			depth--
+			if depth == 0 {
+				return empty
+			}
+
+		case depth == 1 && (k == token.Comma || isComment(k)):
+			return true
+
+		case k != token.Newline:
+			empty = false
+		}
+
+		s.skip(t.Kind)
+	}
+}
+
+func parseSwitch(s scan) (scan, ast.BlockChild) {
+	keyword := s.take(token.Switch, "switch keyword")
+	s.header = true
+	s, value := parseAnyExpr(s, false)
+	s.header = false
+	open := s.take(token.BraceLeft, "opening brace of switch body")
+	s, cases := parseListUntil(s, skipper(token.BraceRight), parseCaseListChild)
+	return s, ast.Switch{keyword.Pos(), value, open.Pos(), cases, s.last}
+}
+
+func parseCaseListChild(s scan) (scan, ast.CaseListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Case, k == token.Default:
+		return parseCase(s)
+
+	case isComment(k):
+		return parseComment(s)
+
+	case k == token.Newline:
+		return parseNewline(s), nil
+
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	}
+
+	unexpected(s, "case, default or closing brace")
+	return s, nil
+}
+
+func parseCase(s scan) (scan, ast.CaseListChild) {
+	pos := s.pos()
+
+	var values []ast.ExprListChild
+
+	isDefault := s.skip(token.Default)
+	if !isDefault {
+		s.take(token.Case, "case keyword")
+
+		s, values = parseNakedList(s, 0, parseExprListChildNaked)
+		if len(values) == 0 {
+			unexpected(s, "case value")
+		}
+	}
+
+	s.take(token.Colon, "colon after case label")
+	end := s.last
+
+	s, body := parseListRecover(s, peekCaseEnd, false, badStmt, parseStatement)
+	if len(body) > 0 {
+		end = body[len(body)-1].End()
+	}
+
+	return s, ast.Case{pos, isDefault, values, body, end}
+}
+
+// peekCaseEnd doesn't consume the next case label or the closing brace.
+func peekCaseEnd(s scan) (scan, bool) {
+	switch s.peek().Kind {
+	case token.BraceRight, token.Case, token.Default:
+		return s, true
+	}
+	return s, false
+}
+
 func parseVariableDecl(s scan) (scan, ast.BlockChild) {
 	pos := s.pos()
 
-	s, names := parseNakedList(s, 0,
-		parseCommaString,
-		parseVariableName,
-	)
+	s, names := parseNakedList(s, 0, parseVariableName)
 	if len(names) == 0 {
-		pan.Panic(newError(pos, "variable declaration: empty list"))
+		pan.Panic(newError(pos, "expected variable name, found "+describe(s.peek())))
 	}
 
-	s.take(token.Colon, "variable declaration: colon expected")
+	s.take(token.Colon, "colon")
 
 	if s.skip(token.Auto) {
 		return s, ast.VariableDecl{pos, names, nil, s.last}
@@ -149,15 +591,12 @@ func parseVariableDecl(s scan) (scan, ast.BlockChild) {
 func parseVariableDef(s scan) (scan, ast.BlockChild) {
 	pos := s.pos()
 
-	s, names := parseNakedList(s, 0,
-		parseCommaString,
-		parseVariableName,
-	)
+	s, names := parseNakedList(s, 0, parseVariableName)
 	if len(names) == 0 {
-		pan.Panic(newError(pos, "variable definition: empty list"))
+		pan.Panic(newError(pos, "expected variable name, found "+describe(s.peek())))
 	}
 
-	s.take(token.Define, "variable definition: operator expected")
+	s.take(token.Define, "define operator")
 
 	s, values := parseExprList(s)
 
@@ -165,6 +604,10 @@ func parseVariableDef(s scan) (scan, ast.BlockChild) {
 }
 
 func parseVariableName(s scan) (scan, string) {
-	name := s.take(token.WordLower, "variable name expected")
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), ""
+	}
+
+	name := s.take(token.WordLower, "variable name")
 	return s, name.Source
 }
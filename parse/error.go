@@ -4,10 +4,42 @@
 package parse
 
 import (
+	"strconv"
+
+	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/internal/position"
 	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
 )
 
 func newError(pos source.Position, msg string) error {
 	return position.NewError(pos, msg)
 }
+
+// unexpected panics with "expected what, found ..." error about the next
+// token.
+func unexpected(s scan, what string) {
+	t := s.peek()
+
+	pos := t.Pos()
+	if t.Kind == 0 {
+		pos = s.last
+	}
+
+	pan.Panic(newError(pos, "expected "+what+", found "+describe(t)))
+}
+
+func describe(t token.Token) string {
+	switch t.Kind {
+	case 0:
+		return "end of file"
+	case token.Newline:
+		return "end of line"
+	case token.BlockComment, token.Comment, token.DocComment:
+		return "comment"
+	case token.Illegal:
+		return "illegal token"
+	default:
+		return strconv.Quote(t.Source)
+	}
+}
@@ -4,296 +4,466 @@
 package parse
 
 import (
+	"slices"
+
 	"github.com/tsavola/dp/ast"
 	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/token"
 )
 
-func parseExpressionInBlock(s scan) (scan, ast.BlockChild) {
-	s, expr := parseAnyExpr(s, false)
-
-	switch s.peek().Kind {
-	case token.Comment, token.Newline, token.Semicolon:
-	default:
-		pan.Panic(newError(s.pos(), "expression: end of statement expected"))
+// parseAnyExpr parses operands joined by infix operators.  Operators are
+// left-associative, and operators of different precedence must not be mixed
+// without parentheses, so the loop needs to remember only the first operator.
+func parseAnyExpr(s scan, multiline bool) (scan, ast.ExprChild) {
+	if multiline {
+		skipNewlines(&s)
 	}
 
-	return s, ast.Expression{expr}
-}
+	s, left := parseOperand(s)
 
-func parseExpressionInExprList(s scan) (scan, ast.ExprListChild) {
-	s, node, ok := parseAssignerDereference(s)
-	if ok {
-		return s, node
-	}
+	var first ast.BinaryOp
 
-	s, expr := parseAnyExpr(s, false)
+	for {
+		if multiline {
+			skipNewlines(&s)
+		}
 
-	if !s.skip(token.Comma) {
-		switch s.peek().Kind {
-		case token.BraceRight, token.Comment, token.Newline, token.ParenRight, token.Semicolon:
-		default:
-			pan.Panic(newError(s.pos(), "end of expression expected"))
+		op, ok := infixOperator(s.peek().Kind)
+		if !ok {
+			return s, left
 		}
+		s.skip(token.Kind(op))
+
+		if first != 0 && op.Precedence() != first.Precedence() {
+			pan.Panic(newError(s.pos(), "operators have different precedence"))
+		}
+		if first == 0 {
+			first = op
+		}
+
+		if multiline {
+			skipNewlines(&s)
+		}
+
+		var right ast.ExprChild
+		s, right = parseOperand(s)
+		left = ast.Binary{left, op, right, s.last}
 	}
+}
 
-	return s, ast.Expression{expr}
+func skipNewlines(s *scan) {
+	for s.skip(token.Newline) {
+	}
 }
 
-func parseAnyExpr(s scan, multiline bool) (scan, ast.ExprChild) {
-	var left ast.ExprChild
-	var operator ast.BinaryOp
-	var secondary bool
+// parseOperand parses an expression which doesn't contain infix operators
+// outside of parentheses.
+func parseOperand(s scan) (scan, ast.ExprChild) {
+	t := s.peek()
 
-	for {
-		if multiline {
-			for s.skip(token.Newline) {
-			}
-		}
+	switch t.Kind {
+	case token.Ampersand:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Address{t.Pos(), expr, s.last}
 
-		var operand ast.ExprChild
-		s, operand = parseAtomicExpr(s)
+	case token.Asterisk:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.PointerDereference{t.Pos(), expr, s.last}
 
-		if left == nil {
-			left = operand
-		} else {
-			left = ast.Binary{left, operator, operand, s.last}
-		}
+	case token.BraceLeft:
+		s.skip(t.Kind)
+		s.take(token.BraceRight, "closing brace of empty value")
+		return s, ast.Empty{t.Pos(), s.last}
 
-		if multiline {
-			for s.skip(token.Newline) {
-			}
-		}
+	case token.Character:
+		s.skip(t.Kind)
+		return s, ast.Character{t.Pos(), t.Source}
 
-		var op ast.BinaryOp
-		var ok bool
-		s, op, ok = parseInfixOperator(s)
-		if !ok {
-			return s, left
-		}
+	case token.Clone:
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Clone{t.Pos(), expr, s.last}
 
-		if secondary && op.Precedence() != operator.Precedence() {
-			pan.Panic(newError(s.pos(), "operators have different precedence"))
+	case token.False, token.True:
+		s.skip(t.Kind)
+		return s, ast.Boolean{t.Pos(), t.Source}
+
+	case token.Float:
+		s.skip(t.Kind)
+		return s, ast.Float{t.Pos(), t.Source}
+
+	case token.Func:
+		s, lit := parseFuncLit(s)
+		return parsePostfix(s, lit)
+
+	case token.Integer:
+		s.skip(t.Kind)
+		return s, ast.Integer{t.Pos(), t.Source}
+
+	case token.Nil:
+		s.skip(t.Kind)
+		return s, ast.Nil{t.Pos(), s.last}
+
+	case token.BracketLeft, token.Colons:
+		s, lit := parseCompositeLit(s)
+		return parsePostfix(s, lit)
+
+	case token.ParenLeft:
+		s.skip(t.Kind)
+		s, expr := parseNestedExpr(s)
+		s.take(token.ParenRight, "closing parenthesis")
+		return parsePostfix(s, expr)
+
+	case token.String:
+		s.skip(t.Kind)
+		return s, ast.String{t.Pos(), t.Source}
+
+	case token.WordLower:
+		s, name := parseSelectorOnly(s)
+		return parsePostfix(s, name)
+
+	case token.WordUpper:
+		if s.lookahead(1).Kind == token.ParenLeft {
+			s, cast := parseCast(s)
+			return parsePostfix(s, cast)
 		}
-		operator = op
-		secondary = true
+		s, lit := parseCompositeLit(s)
+		return parsePostfix(s, lit)
+	}
+
+	if op, ok := prefixOperator(t.Kind); ok {
+		s.skip(t.Kind)
+		s, expr := parseOperand(s)
+		return s, ast.Unary{t.Pos(), op, expr, s.last}
 	}
+
+	unexpected(s, "expression")
+	return s, nil
 }
 
-func parseAtomicExpr(s scan) (scan, ast.ExprChild) {
-	return parse(s,
-		parseAddress,
-		parseCallInExpr,
-		parseCastInExpr,
-		parseCharacter,
-		parseClone,
-		parseEmpty,
-		parseFalse,
-		parseIndexInExpr,
-		parseInteger,
-		parseNil,
-		parseParenthesized,
-		parsePointerDereference,
-		parseSelectorInExpr,
-		parseString,
-		parseTrue,
-		parseUnary,
-	)
+// parseNestedExpr parses an expression enclosed in brackets, where composite
+// literals are always allowed.
+func parseNestedExpr(s scan) (scan, ast.ExprChild) {
+	header := s.header
+	s.header = false
+	s, expr := parseAnyExpr(s, true)
+	s.header = header
+	return s, expr
 }
 
 func parseExprList(s scan) (scan, []ast.ExprListChild) {
-	if s.skip(token.ParenLeft) {
-		return parseListUntil(s, skipper(token.ParenRight),
-			parseCommaInExprList,
-			parseCommentInExprList,
-			parseExpressionInExprList,
-			parseNewlineInExprList,
-		)
+	if s.peek().Kind == token.ParenLeft && !isParenthesizedOperand(s) {
+		s.skip(token.ParenLeft)
+
+		header := s.header
+		s.header = false
+		s, list := parseListUntil(s, skipper(token.ParenRight), parseExprListChild)
+		s.header = header
+		return s, list
 	} else {
-		return parseNakedList(s, 0,
-			parseCommaInExprList,
-			parseExpressionInExprList,
-		)
+		return parseNakedList(s, 0, parseExprListChildNaked)
 	}
 }
 
-func parseAddress(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Ampersand, "address operator expected")
-	s, expr := parseAtomicExpr(s)
-	return s, ast.Address{t.Pos(), expr, s.last}
+// countExprs returns the number of list items which are not comments.
+func countExprs(nodes []ast.ExprListChild) (n int) {
+	for _, node := range nodes {
+		if !ast.IsComment(node) {
+			n++
+		}
+	}
+	return
 }
 
-func parseAssignerDereference(s scan) (scan, ast.AssignerDereference, bool) {
-	start := s
+// isParenthesizedOperand tells if the parenthesis at s is followed by an
+// operator which makes it part of an expression instead of an expression list.
+func isParenthesizedOperand(s scan) bool {
+	var depth int
+
+	for {
+		t := s.peek()
+
+		switch t.Kind {
+		case 0:
+			return false
+
+		case token.BraceLeft, token.BracketLeft, token.ParenLeft:
+			depth++
+
+		case token.BraceRight, token.BracketRight, token.ParenRight:
+			depth--
+			if depth == 0 {
+				s.skip(t.Kind)
+				return continuesOperand(s.peek().Kind)
+			}
+		}
 
-	if !s.skip(token.ParenLeft) {
-		return start, ast.AssignerDereference{}, false
+		s.skip(t.Kind)
 	}
+}
 
-	t := s.peek()
-	if t.Kind != token.WordLower {
-		return start, ast.AssignerDereference{}, false
+// parseExprListChild parses an item of a parenthesized expression list.
+func parseExprListChild(s scan) (scan, ast.ExprListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+
+	case isComment(k):
+		return parseComment(s)
+
+	case k == token.Newline:
+		return parseNewline(s), nil
 	}
-	s.skip(t.Kind)
 
-	if !s.skip(token.ParenRight) {
-		return start, ast.AssignerDereference{}, false
+	return parseExpression(s)
+}
+
+// parseExprListChildNaked parses an item of an expression list which ends at
+// end of statement.
+func parseExprListChildNaked(s scan) (scan, ast.ExprListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+
+	case isComment(k):
+		return parseComment(s)
 	}
 
-	return s, ast.AssignerDereference{start.pos(), t.Source, s.last}, true
+	return parseExpression(s)
 }
 
-func parseAssignerDereferenceInAssignList(s scan) (scan, ast.AssignListChild) {
+// parseExpression parses an expression list item.  It must be followed by a
+// comma or end of list.
+func parseExpression(s scan) (scan, ast.ExprListChild) {
 	s, node, ok := parseAssignerDereference(s)
-	if !ok {
-		pan.Panic(newError(s.pos(), "assigner dereference expected"))
+	if ok {
+		return s, node
+	}
+
+	s, expr := parseAnyExpr(s, false)
+
+	if !s.skip(token.Comma) {
+		switch k := s.peek().Kind; {
+		case k == token.BraceRight, k == token.Colon, k == token.Newline, k == token.ParenRight, k == token.Semicolon, isComment(k):
+		default:
+			unexpected(s, "comma or end of expression list")
+		}
 	}
-	return s, node
+
+	return s, ast.Expression{expr}
 }
 
-func parseCall(s scan, parsers ...func(scan) (scan, ast.ExprListChild)) (scan, ast.Call) {
-	s, name := parseSelectorOnly(s)
+// parseAssignerDereference parses variable name in parentheses.  The scan
+// state is not advanced if the tokens don't match, or if they are part of a
+// larger expression.
+func parseAssignerDereference(s scan) (scan, ast.AssignerDereference, bool) {
+	if s.peek().Kind != token.ParenLeft || s.lookahead(2).Kind != token.ParenRight || continuesOperand(s.lookahead(3).Kind) {
+		return s, ast.AssignerDereference{}, false
+	}
 
-	s.take(token.ParenLeft, "call: opening paren expected")
-	s, args := parseListUntil(s, skipper(token.ParenRight), parsers...)
+	t := s.lookahead(1)
+	if t.Kind != token.WordLower {
+		return s, ast.AssignerDereference{}, false
+	}
 
-	return s, ast.Call{name, args, s.last}
+	pos := s.pos()
+	s.skip(token.ParenLeft)
+	s.skip(token.WordLower)
+	s.skip(token.ParenRight)
+
+	return s, ast.AssignerDereference{pos, t.Source, s.last}, true
 }
 
-func parseCallInExpr(s scan) (scan, ast.ExprChild) {
-	return parseCall(s,
-		parseCommaInExprList,
-		parseCommentInExprList,
-		parseExpressionInExprList,
-		parseNewlineInExprList,
-	)
+// parsePostfix parses a chain of calls, index expressions and field selections
+// following the operand.
+func parsePostfix(s scan, operand ast.ExprChild) (scan, ast.ExprChild) {
+	for {
+		switch s.peek().Kind {
+		case token.BracketLeft:
+			s, operand = parseIndex(s, operand)
+		case token.ParenLeft:
+			s, operand = parseCall(s, operand)
+		case token.Period:
+			s, operand = parseFieldSelect(s, operand)
+		default:
+			return s, operand
+		}
+	}
 }
 
-func parseCallInAssignList(s scan) (scan, ast.AssignListChild) {
-	return parseCall(s,
-		parseCommaInExprList,
-		parseExpressionInExprList,
-		parseNewlineInExprList,
-	)
+func parseCall(s scan, fun ast.ExprChild) (scan, ast.Call) {
+	s.take(token.ParenLeft, "opening parenthesis of call arguments")
+
+	header := s.header
+	s.header = false
+	s, args := parseListUntil(s, skipper(token.ParenRight), parseExprListChild)
+	s.header = header
+
+	return s, ast.Call{fun, args, s.last}
 }
 
 func parseCast(s scan) (scan, ast.Cast) {
-	t := s.take(token.WordUpper, "cast: type name expected")
+	t := s.take(token.WordUpper, "type name")
 
-	s.take(token.ParenLeft, "cast: opening paren expected")
-	s, expr := parseAnyExpr(s, true)
-	s.take(token.ParenRight, "cast: closing paren expected")
+	s.take(token.ParenLeft, "opening parenthesis of type conversion")
+	s, expr := parseNestedExpr(s)
+	s.take(token.ParenRight, "closing parenthesis of type conversion")
 
 	return s, ast.Cast{t.Pos(), t.Source, expr, s.last}
 }
 
-func parseCastInAssignList(s scan) (scan, ast.AssignListChild) { return parseCast(s) }
-func parseCastInExpr(s scan) (scan, ast.ExprChild)             { return parseCast(s) }
+// parseCompositeLit parses array or struct literal.  In if, for and switch
+// headers, struct literals must be enclosed in parentheses so that the type
+// name is not mistaken for the last operand before the body.
+func parseCompositeLit(s scan) (scan, ast.CompositeLit) {
+	pos := s.pos()
+	s, t := parseType(s)
 
-func parseCharacter(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Character, "character literal expected")
-	return s, ast.Character{t.Pos(), t.Source}
-}
+	if s.header && t.Item == nil {
+		pan.Panic(newError(pos, "composite literal in statement header must be parenthesized"))
+	}
 
-func parseClone(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Clone, "clone keyword expected")
-	s, expr := parseAtomicExpr(s)
-	return s, ast.Clone{t.Pos(), expr, s.last}
-}
+	s.take(token.BraceLeft, "opening brace of composite literal")
 
-func parseEmpty(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.BraceLeft, "empty: opening brace expected")
-	s.take(token.BraceRight, "empty: closing brace expected")
-	return s, ast.Empty{t.Pos(), s.last}
-}
+	header := s.header
+	s.header = false
+	s, elems := parseListUntil(s, skipper(token.BraceRight), parseElemListChild)
+	s.header = header
 
-func parseFalse(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.False, "literal false expected")
-	return s, ast.Boolean{t.Pos(), t.Source}
-}
+	checkElements(elems)
 
-func parseIndex(s scan) (scan, ast.Index) {
-	s, name := parseSelectorOnly(s)
-	s.take(token.BracketLeft, "index: opening bracket expected")
-	s, index := parseAnyExpr(s, true)
-	s.take(token.BracketRight, "index: closing bracket expected")
-	return s, ast.Index{name, index, s.last}
+	return s, ast.CompositeLit{pos, t, elems, s.last}
 }
 
-func parseIndexInAssignList(s scan) (scan, ast.AssignListChild) { return parseIndex(s) }
-func parseIndexInExpr(s scan) (scan, ast.ExprChild)             { return parseIndex(s) }
+// checkElements panics if keyed and positional elements are mixed.
+func checkElements(elems []ast.ElemListChild) {
+	var keyed, positional bool
 
-func parseInteger(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Integer, "integer literal expected")
-	return s, ast.Integer{t.Pos(), t.Source}
-}
+	for _, elem := range elems {
+		switch elem.(type) {
+		case ast.KeyValue:
+			keyed = true
+		case ast.Expression:
+			positional = true
+		default:
+			continue
+		}
 
-func parseNil(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Nil, "literal nil expected")
-	return s, ast.Nil{t.Pos(), s.last}
+		if keyed && positional {
+			pan.Panic(newError(elem.Pos(), "composite literal cannot mix keyed and positional elements"))
+		}
+	}
 }
 
-func parseParenthesized(s scan) (scan, ast.ExprChild) {
-	s.take(token.ParenLeft, "expression: opening paren expected")
-	s, expr := parseAnyExpr(s, true)
-	s.take(token.ParenRight, "expression: closing paren expected")
-	return s, expr
-}
+func parseElemListChild(s scan) (scan, ast.ElemListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+
+	case isComment(k):
+		return parseComment(s)
 
-func parsePointerDereference(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.Asterisk, "pointer dereference operator expected")
-	s, expr := parseAtomicExpr(s)
-	return s, ast.PointerDereference{t.Pos(), expr, s.last}
+	case k == token.Newline:
+		return parseNewline(s), nil
+	}
+
+	return parseElement(s)
 }
 
-func parseSelectorOnly(s scan) (scan, ast.Selector) {
+// parseElement parses a composite literal element with optional field name.
+// It must be followed by a comma or end of list.
+func parseElement(s scan) (scan, ast.ElemListChild) {
 	pos := s.pos()
-	name := s.take(token.WordLower, "selector: variable name expected")
 
-	var names []string
+	var key string
+	if s.peek().Kind == token.WordLower && s.lookahead(1).Kind == token.Colon {
+		key = s.take(token.WordLower, "field name").Source
+		s.skip(token.Colon)
+	}
 
-	for {
-		names = append(names, name.Source)
+	s, value := parseAnyExpr(s, false)
 
-		if !s.skip(token.Period) {
-			return s, ast.Selector{pos, names, s.last}
+	if !s.skip(token.Comma) {
+		switch k := s.peek().Kind; {
+		case k == token.BraceRight, k == token.Newline, isComment(k):
+		default:
+			unexpected(s, "comma or closing brace of composite literal")
 		}
+	}
 
-		name = s.take(token.WordLower, "selector: field name expected")
+	if key == "" {
+		return s, ast.Expression{value}
 	}
+	return s, ast.KeyValue{pos, key, value}
 }
 
-func parseSelector(s scan) (scan, ast.Selector) {
-	s, name := parseSelectorOnly(s)
+// parseFieldSelect extends a selector, or selects a field of another kind of
+// expression.
+func parseFieldSelect(s scan, expr ast.ExprChild) (scan, ast.ExprChild) {
+	s.take(token.Period, "period")
+	name := s.take(token.WordLower, "field name")
 
-	switch s.peek().Kind {
-	case token.Colons:
-		pan.Panic(newError(s.pos(), "selector: looks like namespace"))
-	case token.ParenLeft:
-		pan.Panic(newError(s.pos(), "selector used in function call"))
-	case token.BracketLeft:
-		pan.Panic(newError(s.pos(), "selector: looks like index expression"))
+	if x, ok := expr.(ast.Selector); ok {
+		return s, ast.Selector{x.At, append(slices.Clip(x.Name), name.Source), s.last}
 	}
-
-	return s, name
+	return s, ast.FieldSelect{expr, name.Source, s.last}
 }
 
-func parseSelectorInAssignList(s scan) (scan, ast.AssignListChild) { return parseSelector(s) }
-func parseSelectorInExpr(s scan) (scan, ast.ExprChild)             { return parseSelector(s) }
+// parseFuncLit parses function literal with signature and body.
+func parseFuncLit(s scan) (scan, ast.FuncLit) {
+	keyword := s.take(token.Func, "func keyword")
+	s, params, paramsEnd, results := parseSignature(s)
+
+	bodyAt := s.take(token.BraceLeft, "opening brace of function body").Pos()
 
-func parseString(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.String, "string literal expected")
-	return s, ast.String{t.Pos(), t.Source}
+	header := s.header
+	s.header = false
+	s, body := parseStatements(s)
+	s.header = header
+
+	return s, ast.FuncLit{keyword.Pos(), params, paramsEnd, results, bodyAt, body, s.last}
 }
 
-func parseTrue(s scan) (scan, ast.ExprChild) {
-	t := s.take(token.True, "literal true expected")
-	return s, ast.Boolean{t.Pos(), t.Source}
+// parseIndex parses index or slice expression.
+func parseIndex(s scan, expr ast.ExprChild) (scan, ast.ExprChild) {
+	s.take(token.BracketLeft, "opening bracket of index")
+	skipNewlines(&s)
+
+	var low ast.ExprChild
+	if s.peek().Kind != token.Colon {
+		s, low = parseNestedExpr(s)
+		if s.skip(token.BracketRight) {
+			return s, ast.Index{expr, low, s.last}
+		}
+	}
+
+	s.take(token.Colon, "closing bracket of index or colon of slice")
+	skipNewlines(&s)
+
+	var high ast.ExprChild
+	if s.peek().Kind != token.BracketRight {
+		s, high = parseNestedExpr(s)
+	}
+
+	s.take(token.BracketRight, "closing bracket of slice")
+	return s, ast.Slice{expr, low, high, s.last}
 }
 
-func parseUnary(s scan) (scan, ast.ExprChild) {
+func parseSelectorOnly(s scan) (scan, ast.Selector) {
 	pos := s.pos()
-	s, op := parsePrefixOperator(s)
-	s, expr := parseAtomicExpr(s)
-	return s, ast.Unary{pos, op, expr, s.last}
+	name := s.take(token.WordLower, "variable name")
+
+	var names []string
+
+	for {
+		names = append(names, name.Source)
+
+		if !s.skip(token.Period) {
+			return s, ast.Selector{pos, names, s.last}
+		}
+
+		name = s.take(token.WordLower, "field name")
+	}
 }
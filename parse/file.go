@@ -5,6 +5,7 @@
 package parse
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/tsavola/dp/ast"
@@ -14,47 +15,157 @@ import (
 	"github.com/tsavola/dp/token"
 )
 
+// Mode flags.
+type Mode uint
+
+const (
+	// AllErrors makes syntax errors non-fatal.  Declarations and statements
+	// which can't be parsed are represented by ast.BadDecl and ast.BadStmt
+	// nodes.  The nodes are returned together with a source.ErrorList.
+	AllErrors Mode = 1 << iota
+)
+
 func File(tokens []token.Token) ([]ast.FileChild, error) {
-	var nodes []ast.FileChild
+	return FileMode(tokens, 0)
+}
+
+// FileMode is like File, but with mode flags.
+func FileMode(tokens []token.Token, mode Mode) ([]ast.FileChild, error) {
+	return parseFile(&tokenBuffer{buf: tokens, mode: mode}, nil)
+}
+
+// Stream reads tokens from src while parsing.  Tokenization error takes
+// precedence over syntax error.
+func Stream(src TokenSource) ([]ast.FileChild, error) {
+	return StreamMode(src, 0)
+}
+
+// StreamMode is like Stream, but with mode flags.  In AllErrors mode, the
+// errors listed by a lex.Scanner (in its AllErrors mode) are included in the
+// returned error list.
+func StreamMode(src TokenSource, mode Mode) ([]ast.FileChild, error) {
+	return parseFile(&tokenBuffer{source: src, mode: mode}, src)
+}
+
+func parseFile(ts *tokenBuffer, src TokenSource) ([]ast.FileChild, error) {
+	var (
+		end   scan
+		nodes []ast.FileChild
+	)
 
 	err := pan.Recover(func() {
-		nodes = parseTokens(tokens)
+		end, nodes = parseTokens(ts)
 	})
+	if ts.err != nil {
+		return nil, ts.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	labelErrs := checkLabels(nodes)
+	if ts.mode&AllErrors == 0 && len(labelErrs) > 0 {
+		return nil, labelErrs[0]
+	}
+
+	errs := slices.Concat(end.errs, labelErrs)
+	if lister, ok := src.(errorLister); ok {
+		errs = slices.Concat(lister.Errors(), errs)
+	}
+	slices.SortStableFunc(errs, compareErrorPositions)
 
-	return nodes, err
+	return nodes, errs.Err()
 }
 
-func parseTokens(tokens []token.Token) []ast.FileChild {
-	_, nodes := parseListUntil(scan{tokens, source.Position{}}, peekEOF,
-		parseCommentInFile,
-		parseConstantDef,
-		parseFunctionDef,
-		parseImports,
-		parseNewlineInFile,
-		parseSemicolonInFile,
-		parseTypeDef,
-	)
-	return nodes
+func parseTokens(ts *tokenBuffer) (scan, []ast.FileChild) {
+	return parseListRecover(scan{ts, 0, source.Position{}, nil, false}, peekEOF, true, badDecl, parseFileChild)
+}
+
+func parseFileChild(s scan) (scan, ast.FileChild) {
+	switch t := s.peek(); {
+	case isComment(t.Kind):
+		return parseComment(s)
+
+	case t.Kind == token.Import:
+		return parseImports(s)
+
+	case t.Kind == token.Newline:
+		return parseNewline(s), nil
+
+	case t.Kind == token.Semicolon:
+		return parseSemicolon(s), nil
+
+	case t.Kind == token.ParenLeft:
+		return parseFunctionDef(s)
+
+	case t.Kind == token.WordUpper:
+		return parseTypeDef(s)
+
+	case t.Kind == token.WordLower:
+		next := 1
+		if t.Source == "pub" {
+			switch s.lookahead(1).Kind {
+			case token.WordUpper:
+				return parseTypeDef(s)
+			case token.WordLower:
+				next = 2
+			}
+		}
+
+		if s.lookahead(next).Kind == token.Assign {
+			return parseConstantDef(s)
+		}
+		return parseFunctionDef(s)
+	}
+
+	unexpected(s, "declaration")
+	return s, nil
 }
 
 func parseConstantDef(s scan) (scan, ast.FileChild) {
 	var public bool
 	var name token.Token
 
-	t := s.take(token.WordLower, "constant definition: pub keyword or name expected")
+	t := s.take(token.WordLower, "pub keyword or constant name")
 	if t.Source == "pub" {
 		public = true
-		name = s.take(token.WordLower, "constant definition: name expected")
+		name = s.take(token.WordLower, "constant name")
 	} else {
 		name = t
 	}
 
-	s.take(token.Assign, "constant definition: assignment operator expected")
+	s.take(token.Assign, "assignment operator")
 	s, value := parseAnyExpr(s, false)
 
 	return s, ast.ConstantDef{t.Pos(), public, name.Source, value, s.last}
 }
 
+func parseEnumMember(s scan) (scan, ast.EnumMember) {
+	name := s.take(token.WordLower, "enum member name")
+
+	var value ast.ExprChild
+	if s.skip(token.Assign) {
+		s, value = parseAnyExpr(s, false)
+	}
+
+	return s, ast.EnumMember{name.Pos(), name.Source, value, s.last}
+}
+
+func parseEnumListChild(s scan) (scan, ast.EnumListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseEnumMember(s)
+	}
+}
+
 func parseFieldAccess(s scan) (scan, field.Access) {
 	if t, ok := s.skim(token.WordLower); ok {
 		switch t.Source {
@@ -65,18 +176,35 @@ func parseFieldAccess(s scan) (scan, field.Access) {
 		case "assignable":
 			return s, field.AccessAssignable
 		}
-		pan.Panic(newError(t.Pos(), "visible, mutable or assignable keyword expected"))
+		pan.Panic(newError(t.Pos(), "expected visible, mutable or assignable keyword, found "+describe(t)))
 	}
 	return s, field.AccessHidden
 }
 
-func parseFieldInFieldList(s scan) (scan, ast.FieldListChild) {
-	name := s.take(token.WordLower, "field name expected")
+func parseField(s scan) (scan, ast.Field) {
+	name := s.take(token.WordLower, "field name")
 	s, spec := parseTypeSpec(s)
 	s, access := parseFieldAccess(s)
 	return s, ast.Field{name.Pos(), name.Source, spec, access, s.last}
 }
 
+func parseFieldListChild(s scan) (scan, ast.FieldListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Import:
+		return parseImport(s, true)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseField(s)
+	}
+}
+
 func parseFunctionDef(s scan) (scan, ast.FileChild) {
 	pos := s.pos()
 
@@ -93,55 +221,47 @@ func parseFunctionDef(s scan) (scan, ast.FileChild) {
 	}
 
 	if s.skip(token.ParenLeft) {
-		rName = s.take(token.WordLower, "function definition: receiver name expected").Source
+		rName = s.take(token.WordLower, "receiver name").Source
 
 		var t ast.TypeSpec
 		s, t = parseTypeSpec(s)
 		rType = &t
 
-		s.take(token.ParenRight, "function definition: receiver: closing paren expected")
+		s.take(token.ParenRight, "closing paren of receiver")
 
 		if t, ok := s.skim(token.WordLower); ok {
 			name = t.Source
 		}
 	} else {
-		name = s.take(token.WordLower, "function definition: name expected").Source
+		name = s.take(token.WordLower, "function name").Source
 	}
 
-	s.take(token.ParenLeft, "function definition: parameter list expected")
-	s, params := parseListUntil(s, skipper(token.ParenRight),
-		parseCommaInParamList,
-		parseCommentInParamList,
-		parseNewlineInParamList,
-		parseParamInParamList,
-	)
+	s, typeParams := parseTypeParams(s)
+	s, params, paramsEnd, results := parseSignature(s)
+
+	bodyAt := s.take(token.BraceLeft, "opening brace of function body").Pos()
+	s, body := parseStatements(s)
+
+	return s, ast.FunctionDef{pos, public, rName, rType, name, typeParams, params, paramsEnd, results, bodyAt, body, s.last}
+}
+
+// parseSignature parses parameter and result lists of function definition or
+// function literal.
+func parseSignature(s scan) (scan, []ast.ParamListChild, source.Position, []ast.TypeListChild) {
+	s.take(token.ParenLeft, "parameter list")
+	s, params := parseListUntil(s, skipper(token.ParenRight), parseParamListChild)
 	paramsEnd := s.last
 
 	params = fillInFunctionParamTypes(params)
 
-	s, results := parse(s,
-		func(s scan) (scan, []ast.TypeListChild) {
-			return parseNakedList(s, token.BraceLeft,
-				parseCommaInTypeList,
-				parseTypeSpecInTypeList,
-			)
-		},
-
-		func(s scan) (scan, []ast.TypeListChild) {
-			s.take(token.ParenLeft, "function definition: return type list expected")
-			return parseListUntil(s, skipper(token.ParenRight),
-				parseCommaInTypeList,
-				parseCommentInTypeList,
-				parseNewlineInTypeList,
-				parseTypeSpecInTypeList,
-			)
-		},
-	)
-
-	bodyAt := s.take(token.BraceLeft, "function definition: opening brace expected").Pos()
-	s, body := parseStatements(s)
+	var results []ast.TypeListChild
+	if s.skip(token.ParenLeft) {
+		s, results = parseListUntil(s, skipper(token.ParenRight), parseTypeListChild)
+	} else {
+		s, results = parseNakedList(s, token.BraceLeft, parseTypeListChildNaked)
+	}
 
-	return s, ast.FunctionDef{pos, public, rName, rType, name, params, paramsEnd, results, bodyAt, body, s.last}
+	return s, params, paramsEnd, results
 }
 
 func fillInFunctionParamTypes(nodes []ast.ParamListChild) []ast.ParamListChild {
@@ -171,7 +291,7 @@ func parseImport(s scan, requireKeyword bool) (scan, ast.Import) {
 	pos := s.pos()
 
 	if !s.skip(token.Import) && requireKeyword {
-		pan.Panic(newError(pos, "import keyword expected"))
+		unexpected(s, "import keyword")
 	}
 
 	var path string
@@ -184,71 +304,78 @@ func parseImport(s scan, requireKeyword bool) (scan, ast.Import) {
 
 	var names []ast.IdentListChild
 	if s.skip(token.ParenLeft) {
-		s, names = parseListUntil(s, skipper(token.ParenRight),
-			parseCommaInIdentList,
-			parseCommentInIdentList,
-			parseIdentifierInIdentList,
-			parseNewlineInIdentList,
-		)
+		s, names = parseListUntil(s, skipper(token.ParenRight), parseIdentListChild)
 	} else {
-		s, names = parseNakedList(s, 0,
-			parseCommaInIdentList,
-			parseIdentifierInIdentList,
-		)
+		s, names = parseNakedList(s, 0, parseIdentListChildNaked)
 	}
 
 	if !requireKeyword && path == "" && len(names) == 0 {
-		pan.Panic(newError(s.pos(), "import: path or identifier list expected"))
+		unexpected(s, "import path or identifier list")
 	}
 
 	return s, ast.Import{pos, path, names, s.last}
 }
 
-func parseImportInBlock(s scan) (scan, ast.BlockChild)           { return parseImport(s, true) }
-func parseImportInFieldList(s scan) (scan, ast.FieldListChild)   { return parseImport(s, true) }
-func parseImportInImportList(s scan) (scan, ast.ImportListChild) { return parseImport(s, false) }
+func parseImportListChild(s scan) (scan, ast.ImportListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseImport(s, false)
+	}
+}
+
+func parseImportPath(s scan) (scan, ast.ImportListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	}
+
+	path := s.take(token.String, "import path")
+	return s, ast.Import{path.Pos(), path.Source, nil, s.last}
+}
+
+func parseImportPathNaked(s scan) (scan, ast.ImportListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
 
-func parseImportPathInImportList(s scan) (scan, ast.ImportListChild) {
-	path := s.take(token.String, "import path expected")
+	path := s.take(token.String, "import path")
 	return s, ast.Import{path.Pos(), path.Source, nil, s.last}
 }
 
 func parseImports(s scan) (scan, ast.FileChild) {
-	t := s.take(token.Import, "import keyword expected")
+	t := s.take(token.Import, "import keyword")
 
 	var imports []ast.ImportListChild
 
 	switch {
 	case s.peek().Kind == token.String:
-		s, imports = parseNakedList(s, 0,
-			parseCommaInImportList,
-			parseImportPathInImportList,
-		)
+		s, imports = parseNakedList(s, 0, parseImportPathNaked)
 
 	case s.skip(token.ParenLeft):
-		s, imports = parseListUntil(s, skipper(token.ParenRight),
-			parseCommaInImportList,
-			parseCommentInImportList,
-			parseImportPathInImportList,
-			parseNewlineInImportList,
-		)
+		s, imports = parseListUntil(s, skipper(token.ParenRight), parseImportPath)
 
 	default:
-		s.take(token.BraceLeft, "import: opening brace expected")
-		s, imports = parseListUntil(s, skipper(token.BraceRight),
-			parseCommaInImportList,
-			parseCommentInImportList,
-			parseImportInImportList,
-			parseNewlineInImportList,
-			parseSemicolonInImportList,
-		)
+		s.take(token.BraceLeft, "import path or opening brace")
+		s, imports = parseListUntil(s, skipper(token.BraceRight), parseImportListChild)
 	}
 
 	return s, ast.Imports{t.Pos(), imports, s.last}
 }
 
-func parseParamInParamList(s scan) (scan, ast.ParamListChild) {
-	name := s.take(token.WordLower, "parameter name expected")
+func parseParam(s scan) (scan, ast.Parameter) {
+	name := s.take(token.WordLower, "parameter name")
 
 	// Missing type is filled in by parseFunctionDef().
 	var spec ast.TypeSpec
@@ -260,6 +387,51 @@ func parseParamInParamList(s scan) (scan, ast.ParamListChild) {
 	return s, ast.Parameter{name.Pos(), name.Source, spec, s.last}
 }
 
+func parseParamListChild(s scan) (scan, ast.ParamListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseParam(s)
+	}
+}
+
+func parseMethodSig(s scan) (scan, ast.MethodSig) {
+	pos := s.take(token.ParenLeft, "receiver of method signature").Pos()
+
+	var receiver ast.Type
+	receiver.Assigner = s.skip(token.Assign)
+	receiver.Pointer = s.skip(token.Asterisk)
+	receiver.Reference = s.skip(token.Ampersand)
+	receiver.Shared = s.skip(token.Hash)
+
+	s.take(token.ParenRight, "receiver type modifier or closing paren of receiver")
+	name := s.take(token.WordLower, "method name")
+
+	s, params, paramsEnd, results := parseSignature(s)
+
+	return s, ast.MethodSig{pos, receiver, name.Source, params, paramsEnd, results, s.last}
+}
+
+func parseMethodListChild(s scan) (scan, ast.MethodListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	case k == token.Semicolon:
+		return parseSemicolon(s), nil
+	default:
+		return parseMethodSig(s)
+	}
+}
+
 func parseTypeDef(s scan) (scan, ast.FileChild) {
 	pos := s.pos()
 
@@ -269,23 +441,34 @@ func parseTypeDef(s scan) (scan, ast.FileChild) {
 		s.skip(token.WordLower)
 	}
 
-	name := s.take(token.WordUpper, "type definition: name expected")
+	name := s.take(token.WordUpper, "type name")
+	s, typeParams := parseTypeParams(s)
+
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "enum" {
+		if len(typeParams) > 0 {
+			pan.Panic(newError(t.Pos(), "enum type cannot have type parameters"))
+		}
+		s.skip(token.WordLower)
+		s.take(token.BraceLeft, "opening brace of enum definition")
+		s, members := parseListUntil(s, skipper(token.BraceRight), parseEnumListChild)
+		return s, ast.EnumDef{pos, public, name.Source, members, s.last}
+	}
+
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "interface" {
+		s.skip(token.WordLower)
+		s.take(token.BraceLeft, "opening brace of interface definition")
+		s, methods := parseListUntil(s, skipper(token.BraceRight), parseMethodListChild)
+		return s, ast.InterfaceDef{pos, public, name.Source, typeParams, methods, s.last}
+	}
 
 	s, access := parseFieldAccess(s)
 
-	s.take(token.BraceLeft, "type definition: opening brace expected")
-	s, body := parseListUntil(s, skipper(token.BraceRight),
-		parseCommaInFieldList,
-		parseCommentInFieldList,
-		parseFieldInFieldList,
-		parseImportInFieldList,
-		parseNewlineInFieldList,
-		parseSemicolonInFieldList,
-	)
+	s.take(token.BraceLeft, "opening brace of type definition")
+	s, body := parseListUntil(s, skipper(token.BraceRight), parseFieldListChild)
 
 	body = fillInTypeFieldAccess(body, access)
 
-	return s, ast.TypeDef{pos, public, name.Source, body, s.last}
+	return s, ast.TypeDef{pos, public, name.Source, typeParams, body, s.last}
 }
 
 func fillInTypeFieldAccess(nodes []ast.FieldListChild, fallback field.Access) []ast.FieldListChild {
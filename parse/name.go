@@ -8,7 +8,8 @@ import (
 	"github.com/tsavola/dp/token"
 )
 
-func parseQualifiedName(s scan) (scan, ast.QualifiedName) {
+// parseQualifiedName says what was expected if the name is missing.
+func parseQualifiedName(s scan, what string) (scan, ast.QualifiedName) {
 	var name ast.QualifiedName
 
 	if s.skip(token.Colons) {
@@ -18,7 +19,7 @@ func parseQualifiedName(s scan) (scan, ast.QualifiedName) {
 	for {
 		t, ok := s.skim(token.WordLower)
 		if !ok {
-			t = s.take(token.WordUpper, "name expected")
+			t = s.take(token.WordUpper, what)
 		}
 		name = append(name, t.Source)
 
@@ -28,8 +29,28 @@ func parseQualifiedName(s scan) (scan, ast.QualifiedName) {
 	}
 }
 
-func parseIdentifierInIdentList(s scan) (scan, ast.IdentListChild) {
+func parseIdentifier(s scan) (scan, ast.Identifier) {
 	pos := s.pos()
-	s, name := parseQualifiedName(s)
+	s, name := parseQualifiedName(s, "name")
 	return s, ast.Identifier{pos, name, s.last}
 }
+
+func parseIdentListChild(s scan) (scan, ast.IdentListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseIdentifier(s)
+	}
+}
+
+func parseIdentListChildNaked(s scan) (scan, ast.IdentListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+	return parseIdentifier(s)
+}
@@ -8,54 +8,53 @@ import (
 	"github.com/tsavola/dp/token"
 )
 
+func isComment(k token.Kind) bool {
+	switch k {
+	case token.BlockComment, token.Comment, token.DocComment:
+		return true
+	}
+	return false
+}
+
+// isInlineComment tells if the next tokens are block comments which are
+// followed by something else than end of statement or list on the same line.
+func isInlineComment(s scan) bool {
+	n := 0
+	for s.lookahead(n).Kind == token.BlockComment {
+		n++
+	}
+	if n == 0 {
+		return false
+	}
+
+	switch s.lookahead(n).Kind {
+	case 0, token.BraceRight, token.Colon, token.Comment, token.DocComment, token.Newline, token.Semicolon:
+		return false
+	}
+	return true
+}
+
 func parseComma(s scan) scan {
-	s.take(token.Comma, "comma expected")
+	s.take(token.Comma, "comma")
 	return s
 }
 
-func parseCommaInAssignList(s scan) (scan, ast.AssignListChild) { return parseComma(s), nil }
-func parseCommaInExprList(s scan) (scan, ast.ExprListChild)     { return parseComma(s), nil }
-func parseCommaInFieldList(s scan) (scan, ast.FieldListChild)   { return parseComma(s), nil }
-func parseCommaInIdentList(s scan) (scan, ast.IdentListChild)   { return parseComma(s), nil }
-func parseCommaInImportList(s scan) (scan, ast.ImportListChild) { return parseComma(s), nil }
-func parseCommaInParamList(s scan) (scan, ast.ParamListChild)   { return parseComma(s), nil }
-func parseCommaInTypeList(s scan) (scan, ast.TypeListChild)     { return parseComma(s), nil }
-func parseCommaString(s scan) (scan, string)                    { return parseComma(s), "" }
-
 func parseComment(s scan) (scan, ast.Comment) {
-	t := s.take(token.Comment, "comment expected")
+	kind := s.peek().Kind
+	if !isComment(kind) {
+		kind = token.Comment
+	}
+
+	t := s.take(kind, "comment")
 	return s, ast.Comment{t.Pos(), t.Source}
 }
 
-func parseCommentInBlock(s scan) (scan, ast.BlockChild)           { return parseComment(s) }
-func parseCommentInExprList(s scan) (scan, ast.ExprListChild)     { return parseComment(s) }
-func parseCommentInFieldList(s scan) (scan, ast.FieldListChild)   { return parseComment(s) }
-func parseCommentInFile(s scan) (scan, ast.FileChild)             { return parseComment(s) }
-func parseCommentInIdentList(s scan) (scan, ast.IdentListChild)   { return parseComment(s) }
-func parseCommentInImportList(s scan) (scan, ast.ImportListChild) { return parseComment(s) }
-func parseCommentInParamList(s scan) (scan, ast.ParamListChild)   { return parseComment(s) }
-func parseCommentInTypeList(s scan) (scan, ast.TypeListChild)     { return parseComment(s) }
-
 func parseNewline(s scan) scan {
-	s.take(token.Newline, "end of line expected")
+	s.take(token.Newline, "end of line")
 	return s
 }
 
-func parseNewlineInBlock(s scan) (scan, ast.BlockChild)           { return parseNewline(s), nil }
-func parseNewlineInExprList(s scan) (scan, ast.ExprListChild)     { return parseNewline(s), nil }
-func parseNewlineInFieldList(s scan) (scan, ast.FieldListChild)   { return parseNewline(s), nil }
-func parseNewlineInFile(s scan) (scan, ast.FileChild)             { return parseNewline(s), nil }
-func parseNewlineInIdentList(s scan) (scan, ast.IdentListChild)   { return parseNewline(s), nil }
-func parseNewlineInImportList(s scan) (scan, ast.ImportListChild) { return parseNewline(s), nil }
-func parseNewlineInParamList(s scan) (scan, ast.ParamListChild)   { return parseNewline(s), nil }
-func parseNewlineInTypeList(s scan) (scan, ast.TypeListChild)     { return parseNewline(s), nil }
-
 func parseSemicolon(s scan) scan {
-	s.take(token.Semicolon, "semicolon expected")
+	s.take(token.Semicolon, "semicolon")
 	return s
 }
-
-func parseSemicolonInBlock(s scan) (scan, ast.BlockChild)           { return parseSemicolon(s), nil }
-func parseSemicolonInFieldList(s scan) (scan, ast.FieldListChild)   { return parseSemicolon(s), nil }
-func parseSemicolonInFile(s scan) (scan, ast.FileChild)             { return parseSemicolon(s), nil }
-func parseSemicolonInImportList(s scan) (scan, ast.ImportListChild) { return parseSemicolon(s), nil }
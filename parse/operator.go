@@ -5,32 +5,78 @@ package parse
 
 import (
 	"github.com/tsavola/dp/ast"
-	"github.com/tsavola/dp/internal/pan"
 	"github.com/tsavola/dp/token"
 )
 
-func parsePrefixOperator(s scan) (scan, ast.UnaryOp) {
-	switch t := s.peek().Kind; t {
+func prefixOperator(k token.Kind) (ast.UnaryOp, bool) {
+	switch k {
 	case token.Plus, token.Minus, token.Caret, token.Exclamation:
-		s.skim(t)
-		return s, ast.UnaryOp(t)
+		return ast.UnaryOp(k), true
 
 	default:
-		panic(pan.Wrap(newError(s.pos(), "prefix operator expected")))
+		return 0, false
 	}
 }
 
-func parseInfixOperator(s scan) (scan, ast.BinaryOp, bool) {
-	switch t := s.peek().Kind; t {
+func infixOperator(k token.Kind) (ast.BinaryOp, bool) {
+	switch k {
 	case token.Plus, token.Minus, token.Asterisk, token.Slash, token.Percent,
 		token.LogicalAnd, token.LogicalOr,
 		token.AndNot, token.Ampersand, token.Pipe, token.Caret,
 		token.ShiftLeft, token.ShiftRight,
 		token.Equal, token.NotEqual, token.LessOrEqual, token.GreaterOrEqual, token.Less, token.Greater:
-		s.skim(t)
-		return s, ast.BinaryOp(t), true
+		return ast.BinaryOp(k), true
 
 	default:
-		return s, 0, false
+		return 0, false
 	}
 }
+
+// continuesOperand tells if the token extends the preceding operand into a
+// larger expression.
+func continuesOperand(k token.Kind) bool {
+	switch k {
+	case token.BracketLeft, token.ParenLeft, token.Period:
+		return true
+	}
+	_, ok := infixOperator(k)
+	return ok
+}
+
+// assignOperator returns the binary operator of compound assignment, or zero
+// for plain assignment.
+func assignOperator(k token.Kind) (ast.BinaryOp, bool) {
+	switch k {
+	case token.Assign:
+		return 0, true
+	case token.PlusAssign:
+		return ast.OpAdd, true
+	case token.MinusAssign:
+		return ast.OpSubtract, true
+	case token.AsteriskAssign:
+		return ast.OpMultiply, true
+	case token.SlashAssign:
+		return ast.OpDivide, true
+	case token.PercentAssign:
+		return ast.OpRemainder, true
+	case token.AndNotAssign:
+		return ast.OpAndNot, true
+	case token.AmpersandAssign:
+		return ast.OpAnd, true
+	case token.PipeAssign:
+		return ast.OpOr, true
+	case token.CaretAssign:
+		return ast.OpExclusiveOr, true
+	case token.ShiftLeftAssign:
+		return ast.OpShiftLeft, true
+	case token.ShiftRightAssign:
+		return ast.OpShiftRight, true
+	default:
+		return 0, false
+	}
+}
+
+func isAssignOperator(k token.Kind) bool {
+	_, ok := assignOperator(k)
+	return ok
+}
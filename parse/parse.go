@@ -4,55 +4,30 @@
 package parse
 
 import (
-	"errors"
-
-	"github.com/tsavola/dp/internal/pan"
-	"github.com/tsavola/dp/internal/position"
-	"github.com/tsavola/dp/source"
 	"github.com/tsavola/dp/token"
 )
 
-type poser interface {
-	pos() source.Position
-}
-
-func parse[ScanState poser, Result any](s ScanState, parsers ...func(ScanState) (ScanState, Result)) (ScanState, Result) {
-	var errs []error
-
-	for _, f := range parsers {
-		var after ScanState
-		var node Result
-
-		err := pan.Recover(func() {
-			after, node = f(s)
-		})
-		if err == nil {
-			return after, node
-		}
-
-		errs = append(errs, err)
-	}
-
-	if len(errs) == 1 {
-		if _, ok := errors.AsType[position.Error](errs[0]); ok {
-			pan.Panic(errs[0])
-		}
-	}
-
-	panic(pan.Wrap(position.NewError(s.pos(), "syntax error", errs...)))
-}
-
-func parseNakedList[T comparable](s scan, extraTerminator token.Kind, parsers ...func(scan) (scan, T)) (scan, []T) {
+// parseNakedList parses items until end of statement, closing brace, colon,
+// define operator or extraTerminator.  The item parser chooses the production
+// by looking at the next token.  Zero-valued items (separators) are discarded.
+// Inline block comments are passed to the item parser; other comments end the
+// list.
+func parseNakedList[T comparable](s scan, extraTerminator token.Kind, item func(scan) (scan, T)) (scan, []T) {
 	var results []T
 
 	for {
 		switch s.peek().Kind {
-		case token.BraceRight, token.Colon, token.Comment, token.Define, token.Newline, token.Semicolon, extraTerminator:
+		case token.BlockComment:
+			if !isInlineComment(s) {
+				return s, results
+			}
+
+		case token.BraceRight, token.Colon, token.Comment, token.Define, token.DocComment, token.Newline, token.Semicolon, extraTerminator:
 			return s, results
 		}
 
 		var r T
-		s, r = parse(s, parsers...)
+		s, r = item(s)
 
 		var zero T
 		if r != zero {
@@ -61,7 +36,9 @@ func parseNakedList[T comparable](s scan, extraTerminator token.Kind, parsers ..
 	}
 }
 
-func parseListUntil[T comparable](s scan, stop func(scan) (scan, bool), parsers ...func(scan) (scan, T)) (scan, []T) {
+// parseListUntil parses items until stop succeeds.  Zero-valued items
+// (separators) are discarded.
+func parseListUntil[T comparable](s scan, stop func(scan) (scan, bool), item func(scan) (scan, T)) (scan, []T) {
 	var results []T
 
 	for {
@@ -70,7 +47,7 @@ func parseListUntil[T comparable](s scan, stop func(scan) (scan, bool), parsers
 		}
 
 		var r T
-		s, r = parse(s, parsers...)
+		s, r = item(s)
 
 		var zero T
 		if r != zero {
@@ -0,0 +1,365 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tsavola/dp/ast"
+	"github.com/tsavola/dp/lex"
+	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
+)
+
+var equivalenceInputs = []string{
+	"",
+	"\n\n;\n// comment\n/* block */\n/// doc\n",
+	"answer = 42\npub limit = -(x + 1) * 2\n",
+	"x = a + b - c\ny = a * b / c % d\nz = (a + b) * c\n",
+	"x = a && b || c\ny = !a == ^b\nz = a << 1 >> 2 &^ 3 & 4 | 5\n",
+	"x = a <= b; y = a >= b; z = a < b != c > d\n",
+	"x = 'c'\ny = \"s\"\nz = `raw`\nw = 1.5\nv = true\nu = false\nt = nil\ns = {}\n",
+	"x = &a\ny = *b\nz = clone c\nw = &*clone -a\n",
+	"x = f()\ny = a.b.c(1, 2)\nz = a[i + 1]\nw = I64(a +\n\tb)\nv = f(\n\ta, // first\n\tb,\n)\n",
+	"x = (\n\ta\n\t+ b\n)\n",
+	"import \"a\"\n",
+	"import \"a\", \"b\"\n",
+	"import (\n\t\"a\" // comment\n\t\"b\"\n)\n",
+	"import {\n\t\"a\" (X, y)\n\t\"b\" Z, w\n\t(::pkg::Foo)\n\tV\n}\n",
+	"Point {}\n",
+	"pub Point visible {\n\tx, y I64 mutable\n\tz =*&#[I64] assignable; w F64\n\t// comment\n\timport \"a\" (X)\n}\n",
+	"Point hidden {\n\tx I64\n}\n",
+	"f() {}\n",
+	"pub f(a, b I64, c *Foo) I64 {\n}\n",
+	"f(\n\ta I64, // first\n\tb I64\n) (I64, Bool) {}\n",
+	"f() (\n\tI64 // result\n\t::pkg::Foo\n) {}\n",
+	"f() I64, Bool {}\n",
+	"(p =Point) method(x I64) {}\n",
+	"pub (p =Point) (i I64) =I64 {}\n",
+	"f() {\n\tx := 1\n\ty, z := 2, 3\n\tw := (4, 5)\n\tv: I64\n\tu, t: auto\n\ts: *[Foo]\n}\n",
+	"f() {\n\tx = 1\n\tx, y = y, x\n\t(p) = q\n\ta.b = (c)\n\ta[i] = 2\n\tf(x) = 3\n\tI64(x) = 4\n\tx = (a, b)\n}\n",
+	"f() {\n\tg()\n\ta.b(c); d(e) // comment\n\tx + y\n}\n",
+	"f() {\n\treturn\n}\n",
+	"f() {\n\treturn 1\n\treturn 1, 2\n\treturn (1)\n\treturn (1) + 2\n\treturn (1, 2)\n\treturn ()\n\treturn (x)\n}\n",
+	"f() {\n\treturn (\n\t\t1, // first\n\t\t2\n\t)\n\treturn (a /* b */)\n\treturn (g(a /* b */))\n\treturn (\n\t\ta\n\t)\n}\n",
+	"f() { return }\n",
+	"f() {\n\tfor {\n\t\tbreak\n\t}\n\tfor x < 10 {\n\t\tcontinue\n\t}\n}\n",
+	"f() {\n\tif x {\n\t} else {\n\t\ty()\n\t}\n\tif a == b { c() }\n}\n",
+	"f() {\n\t{\n\t\tx := 1\n\t}\n\timport \"a\" (X)\n}\n",
+
+	// Invalid input.
+	"x =\n",
+	"x = 1 +\n",
+	"x = a + b * c\n",
+	"x = a * b + c\n",
+	"x = (a + b\n",
+	"x = f(a b)\n",
+	"x = a[1\n",
+	"x = a.\n",
+	"x = I64 1\n",
+	"x = a::b\n",
+	"x = {1}\n",
+	"import\n",
+	"import {}\n",
+	"import 'a'\n",
+	"import {\n\t`a`\n}\n",
+	"Point {\n\tx\n}\n",
+	"Point private {}\n",
+	"Point {\n\tx I64 private\n}\n",
+	"f(a, b) {}\n",
+	"f(a I64 {}\n",
+	"f() I64\n",
+	"(p) f() {}\n",
+	"f() {\n\tx := := 1\n}\n",
+	"f() {\n\tx = \n}\n",
+	"f() {\n\t= 1\n}\n",
+	"f() {\n\tx y\n}\n",
+	"f() {\n\tx, 1 := 2\n}\n",
+	"f() {\n\tx: \n}\n",
+	"f() {\n\t(1) = 2\n}\n",
+	"f() {\n\treturn 1 2\n}\n",
+	"f() {\n\treturn (1, 2) + 3\n}\n",
+	"f() {\n\tif x\n}\n",
+	"f() {\n\tfor x {\n}\n",
+	"f() {\n\timport\n}\n",
+	"f() {\n",
+	"}\n",
+	"pub\n",
+	"pub 1\n",
+	"Foo\n",
+	"1\n",
+}
+
+// divergentInputs are parsed differently by the trial parser, which ends a
+// parenthesized variable value at the closing parenthesis, and a naked value
+// list at an inline block comment.  The dumps of the resulting declarations
+// are compared.
+var divergentInputs = []struct {
+	input string
+	trial string
+	file  string
+}{
+	{
+		"f() {\n\tx := (y) + 1\n\tz := f((a), b)\n}\n",
+		"FunctionDef{f() Block{VariableDef{x := Expression{Selector{y}}}; Expression{Unary{UnaryOp{+} Integer{1}}}; VariableDef{z := Expression{Call{Selector{f} (AssignerDereference{a}, Expression{Selector{b}})}}}}}",
+		"FunctionDef{f() Block{VariableDef{x := Expression{Binary{Selector{y} BinaryOp{+} Integer{1}}}}; VariableDef{z := Expression{Call{Selector{f} (AssignerDereference{a}, Expression{Selector{b}})}}}}}",
+	},
+	{
+		"f() {\n\tx := /* c */ 1\n\treturn x, /* e */ 2\n}\n",
+		"FunctionDef{f() Block{VariableDef{x := }; Expression{Integer{1}}; Return{ Expression{Selector{x}}}; Expression{Integer{2}}}}",
+		"FunctionDef{f() Block{VariableDef{x := Expression{Integer{1}}}; Return{ Expression{Selector{x}}, Expression{Integer{2}}}}}",
+	},
+}
+
+// trialDivergentFiles contain divergentInputs.  They are not compared.
+var trialDivergentFiles = []string{
+	"comment_test.dp",
+}
+
+// trialUnsupportedFiles use syntax which the trial parser doesn't support.
+// They are only checked to be rejected by it.
+var trialUnsupportedFiles = []string{
+	"assign_test.dp",
+	"composite_test.dp",
+	"defer_test.dp",
+	"else_if_test.dp",
+	"enum_test.dp",
+	"for_test.dp",
+	"func_test.dp",
+	"generic_test.dp",
+	"interface_test.dp",
+	"label_test.dp",
+	"postfix_test.dp",
+	"slice_test.dp",
+	"switch_test.dp",
+}
+
+func TestEquivalence(t *testing.T) {
+	inputs := append(equivalenceInputs, generateSource(50))
+
+	for _, e := range must(os.ReadDir("../testdata")) {
+		if !strings.HasSuffix(e.Name(), ".dp") {
+			continue
+		}
+		input := string(must(os.ReadFile(path.Join("../testdata", e.Name()))))
+
+		if slices.Contains(trialUnsupportedFiles, e.Name()) {
+			tokens := must(lex.FileMode(source.Location(e.Name()), input, lex.LenientNames))
+			if _, err := trialFile(tokens); err == nil {
+				t.Errorf("%s is supported by trial parser", e.Name())
+			}
+			continue
+		}
+		if slices.Contains(trialDivergentFiles, e.Name()) {
+			continue
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	for i, input := range inputs {
+		tokens, err := lex.FileMode(source.Location(fmt.Sprint("input", i)), input, lex.LenientNames)
+		if err != nil {
+			t.Fatalf("input %d %q: %v", i, input, err)
+		}
+
+		expectNodes, expectErr := trialFile(tokens)
+		nodes, err := File(tokens)
+
+		if (err != nil) != (expectErr != nil) {
+			t.Errorf("input %d %q:\n  error:    %v\n  expected: %v", i, input, errorString(err), errorString(expectErr))
+			continue
+		}
+		if !reflect.DeepEqual(nodes, expectNodes) {
+			t.Errorf("input %d %q:\n  nodes:    %v\n  expected: %v", i, input, nodes, expectNodes)
+		}
+	}
+
+	for _, x := range divergentInputs {
+		tokens := must(lex.FileMode(source.Location("input"), x.input, lex.LenientNames))
+
+		if s := dumpNodes(must(trialFile(tokens))); s != x.trial {
+			t.Errorf("%q:\n  trial:    %s\n  expected: %s", x.input, s, x.trial)
+		}
+		if s := dumpNodes(must(File(tokens))); s != x.file {
+			t.Errorf("%q:\n  nodes:    %s\n  expected: %s", x.input, s, x.file)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	for _, x := range []struct {
+		input string
+		err   string
+	}{
+		{"x =\n", `input:0001:004: expected expression, found end of line`},
+		{"x = a + b * c\n", `input:0001:013: operators have different precedence`},
+		{"x = (a + b\n", `input:0002:001: expected closing parenthesis, found end of file`},
+		{"x = f(a b)\n", `input:0001:009: expected comma or end of expression list, found "b"`},
+		{"x = a.\n", `input:0001:007: expected field name, found end of line`},
+		{"import\n", `input:0001:007: expected import path or opening brace, found end of line`},
+		{"Point {\n\tx\n}\n", `input:0002:003: expected type, found end of line`},
+		{"Point private {}\n", `input:0001:007: expected visible, mutable or assignable keyword, found "private"`},
+		{"f(a, b) {}\n", `input:0001:007: expected type, found ")"`},
+		{"f() I64\n", `input:0001:008: expected opening brace of function body, found end of line`},
+		{"f() {\n\tx y\n}\n", `input:0002:004: expected end of statement, found "y"`},
+		{"f() {\n\t= 1\n}\n", `input:0002:002: expected assignment target, found "="`},
+		{"f() {\n\tif x\n}\n", `input:0002:006: expected opening brace of if body, found end of line`},
+		{"f() {\n", `input:0002:001: expected closing brace, found end of file`},
+		{"1\n", `input:0001:001: expected declaration, found "1"`},
+		{"f() {\n\tfor i := 0 {\n\t}\n}\n", `input:0002:008: loop initialization must be followed by semicolon`},
+		{"f() {\n\tfor i = 0 {\n\t}\n}\n", `input:0002:008: loop initialization must be followed by semicolon`},
+		{"f() {\n\tfor i, j, k := range items {\n\t}\n}\n", `input:0002:006: range loop needs index variable and optional item variable`},
+		{"f() {\n\tfor i := range items; i < 1; {\n\t}\n}\n", `input:0002:022: expected opening brace of loop body, found ";"`},
+		{"f() I64 {\n\tx, y += 1\n}\n", `input:0002:007: compound assignment needs single target and value`},
+		{"f() I64 {\n\tx += 1, 2\n}\n", `input:0002:004: compound assignment needs single target and value`},
+		{"f() I64 {\n\tx += (1, 2)\n}\n", `input:0002:004: compound assignment needs single target and value`},
+		{"f() I64 {\n\tx +=\n}\n", `input:0002:006: expected value, found end of line`},
+		{"f() I64 {\n\tswitch x {\n\tcase:\n\t}\n}\n", `input:0003:006: expected case value, found ":"`},
+		{"f() I64 {\n\tswitch x {\n\tcase 1\n\t}\n}\n", `input:0003:008: expected colon after case label, found end of line`},
+		{"f() I64 {\n\tswitch x {\n\treturn 1\n\t}\n}\n", `input:0003:002: expected case, default or closing brace, found "return"`},
+		{"f() {\n\tfor {\n\t\tbreak outer\n\t}\n}\n", `input:0003:003: break label outer doesn't refer to an enclosing loop`},
+		{"f() {\nouter:\n\tfor {}\n\tcontinue outer\n}\n", `input:0004:002: continue label outer doesn't refer to an enclosing loop`},
+		{"f() {\nx:\n\tfor {\n\tx:\n\t\tfor {}\n\t}\n}\n", `input:0004:002: label x is already used by an enclosing loop`},
+		{"f() {\nouter:\n\tfor {\n\t\tg(func() {\n\t\t\tbreak outer\n\t\t})\n\t}\n}\n", `input:0005:004: break label outer doesn't refer to an enclosing loop`},
+		{"f() {\n\tg(func() {\n\t\tcontinue x\n\t})\n\tbreak y\n}\n", `input:0003:003: continue label x doesn't refer to an enclosing loop`},
+		{"f() I64 {\n\tif p == Point{x: 1} {\n\t}\n}\n", `input:0002:010: composite literal in statement header must be parenthesized`},
+		{"f() I64 {\n\tp := Point{x: 1 y: 2}\n}\n", `input:0002:018: expected comma or closing brace of composite literal, found "y"`},
+		{"f() I64 {\n\tp := Point{x: 1, 3}\n}\n", `input:0002:019: composite literal cannot mix keyed and positional elements`},
+		{"f() I64 {\n\tp := Point{1, y: 3}\n}\n", `input:0002:016: composite literal cannot mix keyed and positional elements`},
+		{"f() I64 {\n\ta[1:2] = b\n}\n", `input:0002:002: slice expression cannot be assigned`},
+		{"f() I64 {\n\tx := a[1 2]\n}\n", `input:0002:011: expected closing bracket of index or colon of slice, found "2"`},
+		{"f() I64 {\n\tx := a[1:2:3]\n}\n", `input:0002:012: expected closing bracket of slice, found ":"`},
+		{"Color enum {\n\tRed\n}\n", `input:0002:002: expected enum member name, found "Red"`},
+		{"Color enum red\n", `input:0001:012: expected opening brace of enum definition, found "red"`},
+		{"f(g func I64) {\n}\n", `input:0001:010: expected opening parenthesis of function type parameters, found "I64"`},
+		{"f(g func(I64 I64)) {\n}\n", `input:0001:014: expected comma or closing parenthesis of type list, found "I64"`},
+		{"f() {\n\tx := func() I64\n}\n", `input:0002:017: expected opening brace of function body, found end of line`},
+		{"Pair[a] {}\n", `input:0001:006: expected type parameter name, found "a"`},
+		{"f[T U]() {\n}\n", `input:0001:005: expected comma or closing bracket of type parameter list, found "U"`},
+		{"f(x Pair[]) {\n}\n", `input:0001:010: expected type argument, found "]"`},
+		{"Color[T] enum {}\n", `input:0001:010: enum type cannot have type parameters`},
+		{"Writer interface {\n\twrite()\n}\n", `input:0002:002: expected receiver of method signature, found "write"`},
+		{"Writer interface {\n\t(w =Writer) write()\n}\n", `input:0002:003: expected receiver type modifier or closing paren of receiver, found "w"`},
+		{"Writer interface {\n\t(=) write() {}\n}\n", `input:0002:014: expected receiver of method signature, found "{"`},
+		{"f() {\n\tdefer\n}\n", `input:0002:007: expected expression, found end of line`},
+		{"f() {\n\tdefer g() h()\n}\n", `input:0002:012: expected end of statement, found "h"`},
+	} {
+		tokens, err := lex.File(source.Location("input"), x.input)
+		if err != nil {
+			t.Fatalf("%q: %v", x.input, err)
+		}
+
+		_, err = File(tokens)
+		if s := errorString(err); s != x.err {
+			t.Errorf("%q:\n  error:    %s\n  expected: %s", x.input, s, x.err)
+		}
+	}
+}
+
+func errorString(err error) string {
+	return fmt.Sprint(source.ErrorWithPositionPrefix(err, ""))
+}
+
+func dumpNodes(nodes []ast.FileChild) string {
+	var b strings.Builder
+	for i, node := range nodes {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(node.Dump())
+	}
+	return b.String()
+}
+
+func must[T any](x T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+func BenchmarkFile(b *testing.B) {
+	benchmarkFile(b, File)
+}
+
+func BenchmarkTrialFile(b *testing.B) {
+	benchmarkFile(b, trialFile)
+}
+
+func benchmarkFile[T any](b *testing.B, parse func([]token.Token) (T, error)) {
+	input := generateSource(1000)
+
+	tokens, err := lex.File(source.Location("benchmark"), input)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := parse(tokens); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// generateSource returns a program with the given number of declarations of
+// each kind.
+func generateSource(n int) string {
+	var b strings.Builder
+
+	b.WriteString("import {\n\t\"example\" (Thing, helper)\n}\n\n")
+
+	for i := range n {
+		fmt.Fprintf(&b, `limit_%[1]s = 100 * 2
+
+pub Item%[1]s visible {
+	count I64 mutable
+	total I64 mutable
+	next  *Item%[1]s
+}
+
+// Compute %[1]s does something.
+pub compute_%[1]s(x, y I32, items &[Item%[1]s]) (I32, Bool) {
+	total := 0
+	found: auto
+	for total < x {
+		total = total + (y * 2) - (x >> 1) // Adjust.
+		if (total == limit_%[1]s) && !done(&x) {
+			break
+		} else {
+			continue
+		}
+	}
+
+	text := "item"
+	values[total], (found) = convert(text, 'c'), I64(x + y)
+	item.next.count = clone items[x]
+	return (total, x != y)
+}
+
+`, generateName(i))
+	}
+
+	return b.String()
+}
+
+func generateName(i int) string {
+	s := ""
+	for {
+		s = string(rune('a'+i%26)) + s
+		i /= 26
+		if i == 0 {
+			return s
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+import (
+	"strings"
+
+	"github.com/tsavola/dp/ast"
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
+)
+
+// parseListRecover is like parseListUntil, but in AllErrors mode a syntax
+// error is recorded and the invalid tokens are replaced by a node made by bad.
+func parseListRecover[T comparable](s scan, stop func(scan) (scan, bool), topLevel bool, bad func(source.Position, string) T, item func(scan) (scan, T)) (scan, []T) {
+	if s.tokens.mode&AllErrors == 0 {
+		return parseListUntil(s, stop, item)
+	}
+
+	var (
+		results []T
+		joined  *scan // State before the latest result if no separator followed it.
+	)
+
+	for {
+		if end, ok := stop(s); ok {
+			return end, results
+		}
+
+		var (
+			after scan
+			r     T
+		)
+
+		err := pan.Recover(func() {
+			after, r = item(s)
+		})
+		if err != nil {
+			if _, eof := peekEOF(s); eof {
+				pan.Panic(err)
+			}
+
+			// The latest result is part of the invalid statement.
+			start := s
+			if joined != nil {
+				start = *joined
+				results = results[:len(results)-1]
+			}
+
+			after = skipInvalid(start, topLevel)
+			pos, text, illegal := start.text(after)
+			r = bad(pos, text)
+
+			// Illegal token has already been reported by lexer.
+			if !illegal {
+				after.errs = append(start.errs[:len(start.errs):len(start.errs)], err)
+			}
+		}
+
+		joined = nil
+
+		var zero T
+		if r != zero {
+			results = append(results, r)
+			if err == nil {
+				before := s
+				joined = &before
+			}
+		}
+
+		s = after
+	}
+}
+
+// skipInvalid skips the tokens of an invalid statement or declaration which
+// starts at s.  It stops before a newline or semicolon outside of brackets, or
+// before an unmatched closing brace.  At top level, unmatched closing braces
+// are skipped, and a newline followed by a token in the first column stops
+// also inside brackets.
+func skipInvalid(s scan, topLevel bool) scan {
+	var depth int
+
+	for first := true; ; first = false {
+		t := s.peek()
+
+		switch t.Kind {
+		case 0: // EOF
+			return s
+
+		case token.Newline:
+			if depth == 0 && !first {
+				return s
+			}
+			if topLevel {
+				next := s
+				next.skip(token.Newline)
+				if t := next.peek(); t.Kind != 0 && t.Pos().Column == 1 && !closingBracket(t.Kind) {
+					return s
+				}
+			}
+
+		case token.Semicolon:
+			if depth == 0 && !first {
+				return s
+			}
+
+		case token.BraceLeft, token.BracketLeft, token.ParenLeft:
+			depth++
+
+		case token.BraceRight, token.BracketRight, token.ParenRight:
+			switch {
+			case depth > 0:
+				depth--
+			case t.Kind == token.BraceRight && !first && !topLevel:
+				return s
+			}
+		}
+
+		s.skip(t.Kind)
+	}
+}
+
+func closingBracket(k token.Kind) bool {
+	switch k {
+	case token.BraceRight, token.BracketRight, token.ParenRight:
+		return true
+	}
+	return false
+}
+
+// text between s and end, excluding surrounding space.  Illegal is true if it
+// contains Illegal tokens.
+func (s scan) text(end scan) (pos source.Position, text string, illegal bool) {
+	s.peek() // Skip space.
+
+	tokens := s.tokens.buf[s.index:end.index]
+	for len(tokens) > 0 && tokens[len(tokens)-1].Kind == token.Space {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Source)
+		if t.Kind == token.Illegal {
+			illegal = true
+		}
+	}
+
+	return tokens[0].Pos(), b.String(), illegal
+}
+
+func badDecl(pos source.Position, text string) ast.FileChild  { return ast.BadDecl{pos, text} }
+func badStmt(pos source.Position, text string) ast.BlockChild { return ast.BadStmt{pos, text} }
+
+func compareErrorPositions(a, b error) int {
+	return errorOffset(a) - errorOffset(b)
+}
+
+func errorOffset(err error) int {
+	if e, ok := err.(interface{ Pos() source.Position }); ok {
+		return e.Pos().ByteOffset
+	}
+	return 0
+}
@@ -4,23 +4,67 @@
 package parse
 
 import (
-	"github.com/tsavola/dp/internal/pan"
+	"io"
+
 	"github.com/tsavola/dp/source"
 	"github.com/tsavola/dp/token"
 )
 
+// TokenSource is implemented by lex.Scanner.
+type TokenSource interface {
+	// Next token.  io.EOF is returned at the end of input.
+	Next() (token.Token, error)
+}
+
+// errorLister is implemented by lex.Scanner.  It lists non-fatal errors.
+type errorLister interface {
+	Errors() source.ErrorList
+}
+
+// tokenBuffer is filled from source on demand.  Tokens are retained for
+// lookahead and error recovery.
+type tokenBuffer struct {
+	source TokenSource // Nil when all tokens have been buffered.
+	buf    []token.Token
+	err    error // Non-EOF error returned by source.
+	mode   Mode
+}
+
+// get returns false if there are no more tokens.
+func (ts *tokenBuffer) get(i int) (token.Token, bool) {
+	for ts.source != nil && i >= len(ts.buf) {
+		t, err := ts.source.Next()
+		if err != nil {
+			ts.source = nil
+			if err != io.EOF {
+				ts.err = err
+			}
+			break
+		}
+		ts.buf = append(ts.buf, t)
+	}
+
+	if i < len(ts.buf) {
+		return ts.buf[i], true
+	}
+	return token.Token{}, false
+}
+
 // scan state.
 type scan struct {
-	tokens []token.Token
+	tokens *tokenBuffer
+	index  int
 	last   source.Position
+	errs   source.ErrorList // Recovered errors.
+	header bool             // Composite literal type names are not allowed.
 }
 
 func (s scan) pos() source.Position {
 	s.peek() // Skip space.
-	if len(s.tokens) == 0 {
+	if _, ok := s.tokens.get(s.index); !ok {
 		return source.Position{}
 	}
-	return s.tokens[0].Pos()
+	return s.tokens.buf[s.index].Pos()
 }
 
 // peek at the next token.  Space tokens are skipped.  Last position is not
@@ -28,14 +72,16 @@ func (s scan) pos() source.Position {
 func (s *scan) peek() token.Token {
 	var zero token.Token
 
-	for len(s.tokens) > 0 {
-		if t := s.tokens[0]; t.Kind != token.Space {
+	for {
+		t, ok := s.tokens.get(s.index)
+		if !ok {
+			return zero
+		}
+		if t.Kind != token.Space {
 			return t
 		}
-		s.tokens = s.tokens[1:]
+		s.index++
 	}
-
-	return zero
 }
 
 // skip token if it's next.  Space tokens are skipped.  Last position is
@@ -48,14 +94,14 @@ func (s *scan) skip(wanted token.Kind) bool {
 // skim returns token if it's next.  Space tokens are skipped.  Last position
 // is updated on success.
 func (s *scan) skim(wanted token.Kind) (token.Token, bool) {
-	if s.peek().Kind != wanted {
+	t := s.peek()
+	if t.Kind != wanted {
 		return token.Token{}, false
 	}
 
-	t := s.tokens[0]
-	s.tokens = s.tokens[1:]
+	s.index++
 
-	if len(s.tokens) > 0 {
+	if _, ok := s.tokens.get(s.index); ok {
 		s.last = s.pos()
 	} else {
 		s.last = t.End()
@@ -64,19 +110,33 @@ func (s *scan) skim(wanted token.Kind) (token.Token, bool) {
 	return t, true
 }
 
-// take returns token or panics.  Space tokens are skipped.  Last position is
-// updated on success.
-func (s *scan) take(wanted token.Kind, errorMessage string) token.Token {
+// take returns token or panics with an error which says what was expected.
+// Space tokens are skipped.  Last position is updated on success.
+func (s *scan) take(wanted token.Kind, what string) token.Token {
 	t, ok := s.skim(wanted)
 	if !ok {
-		pan.Panic(newError(s.pos(), errorMessage))
+		unexpected(*s, what)
 	}
 	return t
 }
 
+// lookahead returns the nth token after the next one without consuming
+// anything.  Space tokens are skipped.
+func (s scan) lookahead(n int) token.Token {
+	for {
+		t := s.peek()
+		if n == 0 || t.Kind == 0 {
+			return t
+		}
+		s.index++
+		n--
+	}
+}
+
 func peekEOF(s scan) (scan, bool) {
 	s.peek() // Skip space.
-	return s, len(s.tokens) == 0
+	_, ok := s.tokens.get(s.index)
+	return s, !ok
 }
 
 func skipper(t token.Kind) func(scan) (scan, bool) {
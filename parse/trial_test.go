@@ -0,0 +1,968 @@
+// Copyright (c) 2025 Timo Savola
+// SPDX-License-Identifier: BSD-3-Clause
+
+package parse
+
+// The original backtracking parser, kept for comparison.
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tsavola/dp/ast"
+	"github.com/tsavola/dp/field"
+	"github.com/tsavola/dp/internal/pan"
+	"github.com/tsavola/dp/internal/position"
+	"github.com/tsavola/dp/source"
+	"github.com/tsavola/dp/token"
+)
+
+type trialPoser interface {
+	pos() source.Position
+}
+
+func trialAlternatives[ScanState trialPoser, Result any](s ScanState, parsers ...func(ScanState) (ScanState, Result)) (ScanState, Result) {
+	var errs []error
+
+	for _, f := range parsers {
+		var after ScanState
+		var node Result
+
+		err := pan.Recover(func() {
+			after, node = f(s)
+		})
+		if err == nil {
+			return after, node
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 1 {
+		if _, ok := errors.AsType[position.Error](errs[0]); ok {
+			pan.Panic(errs[0])
+		}
+	}
+
+	panic(pan.Wrap(position.NewError(s.pos(), "syntax error", errs...)))
+}
+
+func trialNakedList[T comparable](s scan, extraTerminator token.Kind, parsers ...func(scan) (scan, T)) (scan, []T) {
+	var results []T
+
+	for {
+		switch s.peek().Kind {
+		case token.BlockComment, token.BraceRight, token.Colon, token.Comment, token.Define, token.DocComment, token.Newline, token.Semicolon, extraTerminator:
+			return s, results
+		}
+
+		var r T
+		s, r = trialAlternatives(s, parsers...)
+
+		var zero T
+		if r != zero {
+			results = append(results, r)
+		}
+	}
+}
+
+func trialListUntil[T comparable](s scan, stop func(scan) (scan, bool), parsers ...func(scan) (scan, T)) (scan, []T) {
+	var results []T
+
+	for {
+		if end, ok := stop(s); ok {
+			return end, results
+		}
+
+		var r T
+		s, r = trialAlternatives(s, parsers...)
+
+		var zero T
+		if r != zero {
+			results = append(results, r)
+		}
+	}
+}
+
+func trialFile(tokens []token.Token) (nodes []ast.FileChild, err error) {
+	err = pan.Recover(func() {
+		_, nodes = trialListUntil(scan{&tokenBuffer{buf: tokens}, 0, source.Position{}, nil, false}, peekEOF,
+			trialCommentInFile,
+			trialConstantDef,
+			trialFunctionDef,
+			trialImports,
+			trialNewlineInFile,
+			trialSemicolonInFile,
+			trialTypeDef,
+		)
+	})
+	return
+}
+
+func trialConstantDef(s scan) (scan, ast.FileChild) {
+	var public bool
+	var name token.Token
+
+	t := s.take(token.WordLower, "constant definition: pub keyword or name expected")
+	if t.Source == "pub" {
+		public = true
+		name = s.take(token.WordLower, "constant definition: name expected")
+	} else {
+		name = t
+	}
+
+	s.take(token.Assign, "constant definition: assignment operator expected")
+	s, value := trialAnyExpr(s, false)
+
+	return s, ast.ConstantDef{t.Pos(), public, name.Source, value, s.last}
+}
+
+func trialFieldAccess(s scan) (scan, field.Access) {
+	if t, ok := s.skim(token.WordLower); ok {
+		switch t.Source {
+		case "visible":
+			return s, field.AccessVisible
+		case "mutable":
+			return s, field.AccessMutable
+		case "assignable":
+			return s, field.AccessAssignable
+		}
+		pan.Panic(newError(t.Pos(), "visible, mutable or assignable keyword expected"))
+	}
+	return s, field.AccessHidden
+}
+
+func trialFieldInFieldList(s scan) (scan, ast.FieldListChild) {
+	name := s.take(token.WordLower, "field name expected")
+	s, spec := trialTypeSpec(s)
+	s, access := trialFieldAccess(s)
+	return s, ast.Field{name.Pos(), name.Source, spec, access, s.last}
+}
+
+func trialFunctionDef(s scan) (scan, ast.FileChild) {
+	pos := s.pos()
+
+	var (
+		public bool
+		rName  string
+		rType  *ast.TypeSpec
+		name   string
+	)
+
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "pub" {
+		s.skip(token.WordLower)
+		public = true
+	}
+
+	if s.skip(token.ParenLeft) {
+		rName = s.take(token.WordLower, "function definition: receiver name expected").Source
+
+		var t ast.TypeSpec
+		s, t = trialTypeSpec(s)
+		rType = &t
+
+		s.take(token.ParenRight, "function definition: receiver: closing paren expected")
+
+		if t, ok := s.skim(token.WordLower); ok {
+			name = t.Source
+		}
+	} else {
+		name = s.take(token.WordLower, "function definition: name expected").Source
+	}
+
+	s.take(token.ParenLeft, "function definition: parameter list expected")
+	s, params := trialListUntil(s, skipper(token.ParenRight),
+		trialCommaInParamList,
+		trialCommentInParamList,
+		trialNewlineInParamList,
+		trialParamInParamList,
+	)
+	paramsEnd := s.last
+
+	params = trialFillInFunctionParamTypes(params)
+
+	s, results := trialAlternatives(s,
+		func(s scan) (scan, []ast.TypeListChild) {
+			return trialNakedList(s, token.BraceLeft,
+				trialCommaInTypeList,
+				trialTypeSpecInTypeList,
+			)
+		},
+
+		func(s scan) (scan, []ast.TypeListChild) {
+			s.take(token.ParenLeft, "function definition: return type list expected")
+			return trialListUntil(s, skipper(token.ParenRight),
+				trialCommaInTypeList,
+				trialCommentInTypeList,
+				trialNewlineInTypeList,
+				trialTypeSpecInTypeList,
+			)
+		},
+	)
+
+	bodyAt := s.take(token.BraceLeft, "function definition: opening brace expected").Pos()
+	s, body := trialStatements(s)
+
+	return s, ast.FunctionDef{pos, public, rName, rType, name, nil, params, paramsEnd, results, bodyAt, body, s.last}
+}
+
+func trialFillInFunctionParamTypes(nodes []ast.ParamListChild) []ast.ParamListChild {
+	var latest ast.TypeSpec
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		ast.VisitParamListChild(nodes[i],
+			func(ast.Comment) {},
+			func(node ast.Parameter) {
+				if trialTypeSpecified(node.Type) {
+					latest = node.Type
+				} else {
+					if !trialTypeSpecified(latest) {
+						pan.Panic(newError(node.EndAt, "function parameter type expected"))
+					}
+					node.Type = latest
+					nodes[i] = node
+				}
+			},
+		)
+	}
+
+	return nodes
+}
+
+func trialImport(s scan, requireKeyword bool) (scan, ast.Import) {
+	pos := s.pos()
+
+	if !s.skip(token.Import) && requireKeyword {
+		pan.Panic(newError(pos, "import keyword expected"))
+	}
+
+	var path string
+	if t, ok := s.skim(token.String); ok {
+		path = t.Source
+		if !strings.HasPrefix(path, `"`) {
+			pan.Panic(newError(t.Pos(), "import path: opening quote expected"))
+		}
+	}
+
+	var names []ast.IdentListChild
+	if s.skip(token.ParenLeft) {
+		s, names = trialListUntil(s, skipper(token.ParenRight),
+			trialCommaInIdentList,
+			trialCommentInIdentList,
+			trialIdentifierInIdentList,
+			trialNewlineInIdentList,
+		)
+	} else {
+		s, names = trialNakedList(s, 0,
+			trialCommaInIdentList,
+			trialIdentifierInIdentList,
+		)
+	}
+
+	if !requireKeyword && path == "" && len(names) == 0 {
+		pan.Panic(newError(s.pos(), "import: path or identifier list expected"))
+	}
+
+	return s, ast.Import{pos, path, names, s.last}
+}
+
+func trialImportInBlock(s scan) (scan, ast.BlockChild)           { return trialImport(s, true) }
+func trialImportInFieldList(s scan) (scan, ast.FieldListChild)   { return trialImport(s, true) }
+func trialImportInImportList(s scan) (scan, ast.ImportListChild) { return trialImport(s, false) }
+
+func trialImportPathInImportList(s scan) (scan, ast.ImportListChild) {
+	path := s.take(token.String, "import path expected")
+	return s, ast.Import{path.Pos(), path.Source, nil, s.last}
+}
+
+func trialImports(s scan) (scan, ast.FileChild) {
+	t := s.take(token.Import, "import keyword expected")
+
+	var imports []ast.ImportListChild
+
+	switch {
+	case s.peek().Kind == token.String:
+		s, imports = trialNakedList(s, 0,
+			trialCommaInImportList,
+			trialImportPathInImportList,
+		)
+
+	case s.skip(token.ParenLeft):
+		s, imports = trialListUntil(s, skipper(token.ParenRight),
+			trialCommaInImportList,
+			trialCommentInImportList,
+			trialImportPathInImportList,
+			trialNewlineInImportList,
+		)
+
+	default:
+		s.take(token.BraceLeft, "import: opening brace expected")
+		s, imports = trialListUntil(s, skipper(token.BraceRight),
+			trialCommaInImportList,
+			trialCommentInImportList,
+			trialImportInImportList,
+			trialNewlineInImportList,
+			trialSemicolonInImportList,
+		)
+	}
+
+	return s, ast.Imports{t.Pos(), imports, s.last}
+}
+
+func trialParamInParamList(s scan) (scan, ast.ParamListChild) {
+	name := s.take(token.WordLower, "parameter name expected")
+
+	// Missing type is filled in by trialFunctionDef().
+	var spec ast.TypeSpec
+	if !s.skip(token.Comma) {
+		s, spec = trialTypeSpec(s)
+		s.skim(token.Comma)
+	}
+
+	return s, ast.Parameter{name.Pos(), name.Source, spec, s.last}
+}
+
+func trialTypeDef(s scan) (scan, ast.FileChild) {
+	pos := s.pos()
+
+	var public bool
+	if t := s.peek(); t.Kind == token.WordLower && t.Source == "pub" {
+		public = true
+		s.skip(token.WordLower)
+	}
+
+	name := s.take(token.WordUpper, "type definition: name expected")
+
+	s, access := trialFieldAccess(s)
+
+	s.take(token.BraceLeft, "type definition: opening brace expected")
+	s, body := trialListUntil(s, skipper(token.BraceRight),
+		trialCommaInFieldList,
+		trialCommentInFieldList,
+		trialFieldInFieldList,
+		trialImportInFieldList,
+		trialNewlineInFieldList,
+		trialSemicolonInFieldList,
+	)
+
+	body = trialFillInTypeFieldAccess(body, access)
+
+	return s, ast.TypeDef{pos, public, name.Source, nil, body, s.last}
+}
+
+func trialFillInTypeFieldAccess(nodes []ast.FieldListChild, fallback field.Access) []ast.FieldListChild {
+	if fallback != 0 {
+		for i, node := range nodes {
+			ast.VisitFieldListChild(node,
+				func(ast.Comment) {},
+				func(node ast.Field) {
+					if node.Access == 0 {
+						node.Access = fallback
+						nodes[i] = node
+					}
+				},
+				func(ast.Import) {},
+			)
+		}
+	}
+
+	return nodes
+}
+
+func trialStatements(s scan) (scan, []ast.BlockChild) {
+	return trialListUntil(s, skipper(token.BraceRight),
+		trialAssign,
+		trialBlock,
+		trialBreak,
+		trialCommentInBlock,
+		trialContinue,
+		trialExpressionInBlock,
+		trialFor,
+		trialIf,
+		trialImportInBlock,
+		trialNewlineInBlock,
+		trialReturn,
+		trialSemicolonInBlock,
+		trialVariableDecl,
+		trialVariableDef,
+	)
+}
+
+func trialAssign(s scan) (scan, ast.BlockChild) {
+	s, names := trialNakedList(s, token.Assign,
+		trialAssignerDereferenceInAssignList,
+		trialCallInAssignList,
+		trialCastInAssignList,
+		trialCommaInAssignList,
+		trialIndexInAssignList,
+		trialSelectorInAssignList,
+	)
+	if len(names) == 0 {
+		pan.Panic(newError(s.pos(), "assign: empty list"))
+	}
+
+	s.take(token.Assign, "assign: operator expected")
+
+	s, values := trialExprList(s)
+	if len(values) == 0 {
+		pan.Panic(newError(s.pos(), "assign: empty list"))
+	}
+
+	return s, ast.Assign{names[0].Pos(), names, 0, values, s.last}
+}
+
+func trialBlock(s scan) (scan, ast.BlockChild) {
+	t := s.take(token.BraceLeft, "block: opening brace expected")
+	s, body := trialStatements(s)
+	return s, ast.Block{t.Pos(), body, s.last}
+}
+
+func trialBreak(s scan) (scan, ast.BlockChild) {
+	t := s.take(token.Break, "break keyword expected")
+	return s, ast.Break{t.Pos(), "", s.last}
+}
+
+func trialContinue(s scan) (scan, ast.BlockChild) {
+	t := s.take(token.Continue, "continue keyword expected")
+	return s, ast.Continue{t.Pos(), "", s.last}
+}
+
+func trialFor(s scan) (scan, ast.BlockChild) {
+	keyword := s.take(token.For, "for keyword expected")
+
+	var test ast.ExprChild
+
+	open, ok := s.skim(token.BraceLeft)
+	if !ok {
+		s, test = trialAnyExpr(s, false)
+		open = s.take(token.BraceLeft, "for: opening brace expected")
+	}
+
+	s, body := trialStatements(s)
+	return s, ast.For{keyword.Pos(), "", nil, test, nil, nil, open.Pos(), body, s.last}
+}
+
+func trialIf(s scan) (scan, ast.BlockChild) {
+	keyword := s.take(token.If, "if keyword expected")
+	s, test := trialAnyExpr(s, false)
+
+	thenAt := s.take(token.BraceLeft, "if: opening brace expected").Pos()
+	s, then := trialStatements(s)
+	thenEnd := s.last
+
+	var els []ast.BlockChild
+	if s.skip(token.Else) {
+		s.take(token.BraceLeft, "else: opening brace expected")
+		s, els = trialStatements(s)
+	}
+
+	return s, ast.If{keyword.Pos(), test, thenAt, then, thenEnd, false, els, s.last}
+}
+
+func trialReturn(s scan) (scan, ast.BlockChild) {
+	keyword := s.take(token.Return, "return keyword expected")
+
+	s, values := trialAlternatives(s,
+		func(s scan) (scan, []ast.ExprListChild) {
+			return trialNakedList(s, 0,
+				trialCommaInExprList,
+				trialExpressionInExprList,
+			)
+		},
+
+		func(s scan) (scan, []ast.ExprListChild) {
+			s.take(token.ParenLeft, "return value list expected")
+			return trialListUntil(s, skipper(token.ParenRight),
+				trialCommaInExprList,
+				trialCommentInExprList,
+				trialExpressionInExprList,
+				trialNewlineInExprList,
+			)
+		},
+	)
+
+	return s, ast.Return{keyword.Pos(), values, s.last}
+}
+
+func trialVariableDecl(s scan) (scan, ast.BlockChild) {
+	pos := s.pos()
+
+	s, names := trialNakedList(s, 0,
+		trialCommaString,
+		trialVariableName,
+	)
+	if len(names) == 0 {
+		pan.Panic(newError(pos, "variable declaration: empty list"))
+	}
+
+	s.take(token.Colon, "variable declaration: colon expected")
+
+	if s.skip(token.Auto) {
+		return s, ast.VariableDecl{pos, names, nil, s.last}
+	}
+
+	s, spec := trialTypeSpec(s)
+
+	return s, ast.VariableDecl{pos, names, &spec, s.last}
+}
+
+func trialVariableDef(s scan) (scan, ast.BlockChild) {
+	pos := s.pos()
+
+	s, names := trialNakedList(s, 0,
+		trialCommaString,
+		trialVariableName,
+	)
+	if len(names) == 0 {
+		pan.Panic(newError(pos, "variable definition: empty list"))
+	}
+
+	s.take(token.Define, "variable definition: operator expected")
+
+	s, values := trialExprList(s)
+
+	return s, ast.VariableDef{pos, names, values, s.last}
+}
+
+func trialVariableName(s scan) (scan, string) {
+	name := s.take(token.WordLower, "variable name expected")
+	return s, name.Source
+}
+
+func trialExpressionInBlock(s scan) (scan, ast.BlockChild) {
+	s, expr := trialAnyExpr(s, false)
+
+	switch s.peek().Kind {
+	case token.BlockComment, token.Comment, token.DocComment, token.Newline, token.Semicolon:
+	default:
+		pan.Panic(newError(s.pos(), "expression: end of statement expected"))
+	}
+
+	return s, ast.Expression{expr}
+}
+
+func trialExpressionInExprList(s scan) (scan, ast.ExprListChild) {
+	s, node, ok := trialAssignerDereference(s)
+	if ok {
+		return s, node
+	}
+
+	s, expr := trialAnyExpr(s, false)
+
+	if !s.skip(token.Comma) {
+		switch s.peek().Kind {
+		case token.BlockComment, token.BraceRight, token.Comment, token.DocComment, token.Newline, token.ParenRight, token.Semicolon:
+		default:
+			pan.Panic(newError(s.pos(), "end of expression expected"))
+		}
+	}
+
+	return s, ast.Expression{expr}
+}
+
+func trialAnyExpr(s scan, multiline bool) (scan, ast.ExprChild) {
+	var left ast.ExprChild
+	var operator ast.BinaryOp
+	var secondary bool
+
+	for {
+		if multiline {
+			for s.skip(token.Newline) {
+			}
+		}
+
+		var operand ast.ExprChild
+		s, operand = trialAtomicExpr(s)
+
+		if left == nil {
+			left = operand
+		} else {
+			left = ast.Binary{left, operator, operand, s.last}
+		}
+
+		if multiline {
+			for s.skip(token.Newline) {
+			}
+		}
+
+		var op ast.BinaryOp
+		var ok bool
+		s, op, ok = trialInfixOperator(s)
+		if !ok {
+			return s, left
+		}
+
+		if secondary && op.Precedence() != operator.Precedence() {
+			pan.Panic(newError(s.pos(), "operators have different precedence"))
+		}
+		operator = op
+		secondary = true
+	}
+}
+
+func trialAtomicExpr(s scan) (scan, ast.ExprChild) {
+	return trialAlternatives(s,
+		trialAddress,
+		trialCallInExpr,
+		trialCastInExpr,
+		trialCharacter,
+		trialClone,
+		trialEmpty,
+		trialFalse,
+		trialFloat,
+		trialIndexInExpr,
+		trialInteger,
+		trialNil,
+		trialParenthesized,
+		trialPointerDereference,
+		trialSelectorInExpr,
+		trialString,
+		trialTrue,
+		trialUnary,
+	)
+}
+
+func trialExprList(s scan) (scan, []ast.ExprListChild) {
+	if s.skip(token.ParenLeft) {
+		return trialListUntil(s, skipper(token.ParenRight),
+			trialCommaInExprList,
+			trialCommentInExprList,
+			trialExpressionInExprList,
+			trialNewlineInExprList,
+		)
+	} else {
+		return trialNakedList(s, 0,
+			trialCommaInExprList,
+			trialExpressionInExprList,
+		)
+	}
+}
+
+func trialAddress(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Ampersand, "address operator expected")
+	s, expr := trialAtomicExpr(s)
+	return s, ast.Address{t.Pos(), expr, s.last}
+}
+
+func trialAssignerDereference(s scan) (scan, ast.AssignerDereference, bool) {
+	start := s
+
+	if !s.skip(token.ParenLeft) {
+		return start, ast.AssignerDereference{}, false
+	}
+
+	t := s.peek()
+	if t.Kind != token.WordLower {
+		return start, ast.AssignerDereference{}, false
+	}
+	s.skip(t.Kind)
+
+	if !s.skip(token.ParenRight) {
+		return start, ast.AssignerDereference{}, false
+	}
+
+	return s, ast.AssignerDereference{start.pos(), t.Source, s.last}, true
+}
+
+func trialAssignerDereferenceInAssignList(s scan) (scan, ast.AssignListChild) {
+	s, node, ok := trialAssignerDereference(s)
+	if !ok {
+		pan.Panic(newError(s.pos(), "assigner dereference expected"))
+	}
+	return s, node
+}
+
+func trialCall(s scan, parsers ...func(scan) (scan, ast.ExprListChild)) (scan, ast.Call) {
+	s, name := trialSelectorOnly(s)
+
+	s.take(token.ParenLeft, "call: opening paren expected")
+	s, args := trialListUntil(s, skipper(token.ParenRight), parsers...)
+
+	return s, ast.Call{name, args, s.last}
+}
+
+func trialCallInExpr(s scan) (scan, ast.ExprChild) {
+	return trialCall(s,
+		trialCommaInExprList,
+		trialCommentInExprList,
+		trialExpressionInExprList,
+		trialNewlineInExprList,
+	)
+}
+
+func trialCallInAssignList(s scan) (scan, ast.AssignListChild) {
+	return trialCall(s,
+		trialCommaInExprList,
+		trialExpressionInExprList,
+		trialNewlineInExprList,
+	)
+}
+
+func trialCast(s scan) (scan, ast.Cast) {
+	t := s.take(token.WordUpper, "cast: type name expected")
+
+	s.take(token.ParenLeft, "cast: opening paren expected")
+	s, expr := trialAnyExpr(s, true)
+	s.take(token.ParenRight, "cast: closing paren expected")
+
+	return s, ast.Cast{t.Pos(), t.Source, expr, s.last}
+}
+
+func trialCastInAssignList(s scan) (scan, ast.AssignListChild) { return trialCast(s) }
+func trialCastInExpr(s scan) (scan, ast.ExprChild)             { return trialCast(s) }
+
+func trialCharacter(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Character, "character literal expected")
+	return s, ast.Character{t.Pos(), t.Source}
+}
+
+func trialClone(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Clone, "clone keyword expected")
+	s, expr := trialAtomicExpr(s)
+	return s, ast.Clone{t.Pos(), expr, s.last}
+}
+
+func trialEmpty(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.BraceLeft, "empty: opening brace expected")
+	s.take(token.BraceRight, "empty: closing brace expected")
+	return s, ast.Empty{t.Pos(), s.last}
+}
+
+func trialFalse(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.False, "literal false expected")
+	return s, ast.Boolean{t.Pos(), t.Source}
+}
+
+func trialFloat(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Float, "floating-point literal expected")
+	return s, ast.Float{t.Pos(), t.Source}
+}
+
+func trialIndex(s scan) (scan, ast.Index) {
+	s, name := trialSelectorOnly(s)
+	s.take(token.BracketLeft, "index: opening bracket expected")
+	s, index := trialAnyExpr(s, true)
+	s.take(token.BracketRight, "index: closing bracket expected")
+	return s, ast.Index{name, index, s.last}
+}
+
+func trialIndexInAssignList(s scan) (scan, ast.AssignListChild) { return trialIndex(s) }
+func trialIndexInExpr(s scan) (scan, ast.ExprChild)             { return trialIndex(s) }
+
+func trialInteger(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Integer, "integer literal expected")
+	return s, ast.Integer{t.Pos(), t.Source}
+}
+
+func trialNil(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Nil, "literal nil expected")
+	return s, ast.Nil{t.Pos(), s.last}
+}
+
+func trialParenthesized(s scan) (scan, ast.ExprChild) {
+	s.take(token.ParenLeft, "expression: opening paren expected")
+	s, expr := trialAnyExpr(s, true)
+	s.take(token.ParenRight, "expression: closing paren expected")
+	return s, expr
+}
+
+func trialPointerDereference(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.Asterisk, "pointer dereference operator expected")
+	s, expr := trialAtomicExpr(s)
+	return s, ast.PointerDereference{t.Pos(), expr, s.last}
+}
+
+func trialSelectorOnly(s scan) (scan, ast.Selector) {
+	pos := s.pos()
+	name := s.take(token.WordLower, "selector: variable name expected")
+
+	var names []string
+
+	for {
+		names = append(names, name.Source)
+
+		if !s.skip(token.Period) {
+			return s, ast.Selector{pos, names, s.last}
+		}
+
+		name = s.take(token.WordLower, "selector: field name expected")
+	}
+}
+
+func trialSelector(s scan) (scan, ast.Selector) {
+	s, name := trialSelectorOnly(s)
+
+	switch s.peek().Kind {
+	case token.Colons:
+		pan.Panic(newError(s.pos(), "selector: looks like namespace"))
+	case token.ParenLeft:
+		pan.Panic(newError(s.pos(), "selector used in function call"))
+	case token.BracketLeft:
+		pan.Panic(newError(s.pos(), "selector: looks like index expression"))
+	}
+
+	return s, name
+}
+
+func trialSelectorInAssignList(s scan) (scan, ast.AssignListChild) { return trialSelector(s) }
+func trialSelectorInExpr(s scan) (scan, ast.ExprChild)             { return trialSelector(s) }
+
+func trialString(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.String, "string literal expected")
+	return s, ast.String{t.Pos(), t.Source}
+}
+
+func trialTrue(s scan) (scan, ast.ExprChild) {
+	t := s.take(token.True, "literal true expected")
+	return s, ast.Boolean{t.Pos(), t.Source}
+}
+
+func trialUnary(s scan) (scan, ast.ExprChild) {
+	pos := s.pos()
+	s, op := trialPrefixOperator(s)
+	s, expr := trialAtomicExpr(s)
+	return s, ast.Unary{pos, op, expr, s.last}
+}
+
+func trialQualifiedName(s scan) (scan, ast.QualifiedName) {
+	var name ast.QualifiedName
+
+	if s.skip(token.Colons) {
+		name = append(name, "")
+	}
+
+	for {
+		t, ok := s.skim(token.WordLower)
+		if !ok {
+			t = s.take(token.WordUpper, "name expected")
+		}
+		name = append(name, t.Source)
+
+		if !s.skip(token.Colons) {
+			return s, name
+		}
+	}
+}
+
+func trialIdentifierInIdentList(s scan) (scan, ast.IdentListChild) {
+	pos := s.pos()
+	s, name := trialQualifiedName(s)
+	return s, ast.Identifier{pos, name, s.last}
+}
+
+func trialComma(s scan) scan {
+	s.take(token.Comma, "comma expected")
+	return s
+}
+
+func trialCommaInAssignList(s scan) (scan, ast.AssignListChild) { return trialComma(s), nil }
+func trialCommaInExprList(s scan) (scan, ast.ExprListChild)     { return trialComma(s), nil }
+func trialCommaInFieldList(s scan) (scan, ast.FieldListChild)   { return trialComma(s), nil }
+func trialCommaInIdentList(s scan) (scan, ast.IdentListChild)   { return trialComma(s), nil }
+func trialCommaInImportList(s scan) (scan, ast.ImportListChild) { return trialComma(s), nil }
+func trialCommaInParamList(s scan) (scan, ast.ParamListChild)   { return trialComma(s), nil }
+func trialCommaInTypeList(s scan) (scan, ast.TypeListChild)     { return trialComma(s), nil }
+func trialCommaString(s scan) (scan, string)                    { return trialComma(s), "" }
+
+func trialComment(s scan) (scan, ast.Comment) {
+	kind := s.peek().Kind
+	switch kind {
+	case token.BlockComment, token.DocComment:
+	default:
+		kind = token.Comment
+	}
+
+	t := s.take(kind, "comment expected")
+	return s, ast.Comment{t.Pos(), t.Source}
+}
+
+func trialCommentInBlock(s scan) (scan, ast.BlockChild)           { return trialComment(s) }
+func trialCommentInExprList(s scan) (scan, ast.ExprListChild)     { return trialComment(s) }
+func trialCommentInFieldList(s scan) (scan, ast.FieldListChild)   { return trialComment(s) }
+func trialCommentInFile(s scan) (scan, ast.FileChild)             { return trialComment(s) }
+func trialCommentInIdentList(s scan) (scan, ast.IdentListChild)   { return trialComment(s) }
+func trialCommentInImportList(s scan) (scan, ast.ImportListChild) { return trialComment(s) }
+func trialCommentInParamList(s scan) (scan, ast.ParamListChild)   { return trialComment(s) }
+func trialCommentInTypeList(s scan) (scan, ast.TypeListChild)     { return trialComment(s) }
+
+func trialNewline(s scan) scan {
+	s.take(token.Newline, "end of line expected")
+	return s
+}
+
+func trialNewlineInBlock(s scan) (scan, ast.BlockChild)           { return trialNewline(s), nil }
+func trialNewlineInExprList(s scan) (scan, ast.ExprListChild)     { return trialNewline(s), nil }
+func trialNewlineInFieldList(s scan) (scan, ast.FieldListChild)   { return trialNewline(s), nil }
+func trialNewlineInFile(s scan) (scan, ast.FileChild)             { return trialNewline(s), nil }
+func trialNewlineInIdentList(s scan) (scan, ast.IdentListChild)   { return trialNewline(s), nil }
+func trialNewlineInImportList(s scan) (scan, ast.ImportListChild) { return trialNewline(s), nil }
+func trialNewlineInParamList(s scan) (scan, ast.ParamListChild)   { return trialNewline(s), nil }
+func trialNewlineInTypeList(s scan) (scan, ast.TypeListChild)     { return trialNewline(s), nil }
+
+func trialSemicolon(s scan) scan {
+	s.take(token.Semicolon, "semicolon expected")
+	return s
+}
+
+func trialSemicolonInBlock(s scan) (scan, ast.BlockChild)           { return trialSemicolon(s), nil }
+func trialSemicolonInFieldList(s scan) (scan, ast.FieldListChild)   { return trialSemicolon(s), nil }
+func trialSemicolonInFile(s scan) (scan, ast.FileChild)             { return trialSemicolon(s), nil }
+func trialSemicolonInImportList(s scan) (scan, ast.ImportListChild) { return trialSemicolon(s), nil }
+
+func trialPrefixOperator(s scan) (scan, ast.UnaryOp) {
+	switch t := s.peek().Kind; t {
+	case token.Plus, token.Minus, token.Caret, token.Exclamation:
+		s.skim(t)
+		return s, ast.UnaryOp(t)
+
+	default:
+		panic(pan.Wrap(newError(s.pos(), "prefix operator expected")))
+	}
+}
+
+func trialInfixOperator(s scan) (scan, ast.BinaryOp, bool) {
+	switch t := s.peek().Kind; t {
+	case token.Plus, token.Minus, token.Asterisk, token.Slash, token.Percent,
+		token.LogicalAnd, token.LogicalOr,
+		token.AndNot, token.Ampersand, token.Pipe, token.Caret,
+		token.ShiftLeft, token.ShiftRight,
+		token.Equal, token.NotEqual, token.LessOrEqual, token.GreaterOrEqual, token.Less, token.Greater:
+		s.skim(t)
+		return s, ast.BinaryOp(t), true
+
+	default:
+		return s, 0, false
+	}
+}
+
+func trialType(s scan) (scan, ast.Type) {
+	var t ast.Type
+
+	t.Assigner = s.skip(token.Assign)
+	t.Pointer = s.skip(token.Asterisk)
+	t.Reference = s.skip(token.Ampersand)
+	t.Shared = s.skip(token.Hash)
+
+	if s.skip(token.BracketLeft) {
+		var item ast.Type
+		s, item = trialType(s)
+		t.Item = &item
+		s.take(token.BracketRight, "type: array closing bracket expected")
+	} else {
+		s, t.Name = trialQualifiedName(s)
+	}
+
+	return s, t
+}
+
+func trialTypeSpec(s scan) (scan, ast.TypeSpec) {
+	pos := s.pos()
+	s, t := trialType(s)
+	return s, ast.TypeSpec{pos, t, s.last}
+}
+
+func trialTypeSpecInTypeList(s scan) (scan, ast.TypeListChild) { return trialTypeSpec(s) }
+
+func trialTypeSpecified(t ast.TypeSpec) bool {
+	return t.Item != nil || len(t.Name) > 0
+}
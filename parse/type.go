@@ -16,26 +16,123 @@ func parseType(s scan) (scan, ast.Type) {
 	t.Reference = s.skip(token.Ampersand)
 	t.Shared = s.skip(token.Hash)
 
-	if s.skip(token.BracketLeft) {
+	switch {
+	case s.skip(token.BracketLeft):
 		var item ast.Type
 		s, item = parseType(s)
 		t.Item = &item
-		s.take(token.BracketRight, "type: array closing bracket expected")
-	} else {
-		s, t.Name = parseQualifiedName(s)
+		s.take(token.BracketRight, "closing bracket of array type")
+
+	case s.skip(token.Func):
+		s.take(token.ParenLeft, "opening parenthesis of function type parameters")
+
+		var fn ast.FuncType
+		s, fn.Params = parseTypeList(s, token.ParenRight, "comma or closing parenthesis of type list")
+
+		if s.skip(token.ParenLeft) {
+			s, fn.Results = parseTypeList(s, token.ParenRight, "comma or closing parenthesis of type list")
+		} else if isTypeStart(s) {
+			var result ast.Type
+			s, result = parseType(s)
+			fn.Results = []ast.Type{result}
+		}
+
+		t.Func = &fn
+
+	default:
+		s, t.Name = parseQualifiedName(s, "type")
+
+		if s.skip(token.BracketLeft) {
+			if s.peek().Kind == token.BracketRight {
+				unexpected(s, "type argument")
+			}
+			s, t.Args = parseTypeList(s, token.BracketRight, "comma or closing bracket of type argument list")
+		}
 	}
 
 	return s, t
 }
 
+// parseTypeList parses types until the closing token.
+func parseTypeList(s scan, closing token.Kind, what string) (scan, []ast.Type) {
+	var types []ast.Type
+
+	for !s.skip(closing) {
+		var t ast.Type
+		s, t = parseType(s)
+		types = append(types, t)
+
+		if !s.skip(token.Comma) {
+			s.take(closing, what)
+			break
+		}
+	}
+
+	return s, types
+}
+
+// parseTypeParams parses optional type parameter list of a generic type or
+// function definition.
+func parseTypeParams(s scan) (scan, []string) {
+	if !s.skip(token.BracketLeft) {
+		return s, nil
+	}
+
+	var names []string
+
+	for {
+		names = append(names, s.take(token.WordUpper, "type parameter name").Source)
+
+		if !s.skip(token.Comma) {
+			s.take(token.BracketRight, "comma or closing bracket of type parameter list")
+			break
+		}
+		if s.skip(token.BracketRight) {
+			break
+		}
+	}
+
+	return s, names
+}
+
+// isTypeStart tells if the next token can start a function result type.
+// Namespace must be explicit if a result type starts with lower-case word.
+func isTypeStart(s scan) bool {
+	switch s.peek().Kind {
+	case token.Ampersand, token.Assign, token.Asterisk, token.BracketLeft, token.Colons, token.Func, token.Hash, token.WordUpper:
+		return true
+	case token.WordLower:
+		return s.lookahead(1).Kind == token.Colons
+	}
+	return false
+}
+
 func parseTypeSpec(s scan) (scan, ast.TypeSpec) {
 	pos := s.pos()
 	s, t := parseType(s)
 	return s, ast.TypeSpec{pos, t, s.last}
 }
 
-func parseTypeSpecInTypeList(s scan) (scan, ast.TypeListChild) { return parseTypeSpec(s) }
+func parseTypeListChild(s scan) (scan, ast.TypeListChild) {
+	switch k := s.peek().Kind; {
+	case k == token.Comma:
+		return parseComma(s), nil
+	case isComment(k):
+		return parseComment(s)
+	case k == token.Newline:
+		return parseNewline(s), nil
+	default:
+		return parseTypeSpec(s)
+	}
+}
+
+func parseTypeListChildNaked(s scan) (scan, ast.TypeListChild) {
+	if s.peek().Kind == token.Comma {
+		return parseComma(s), nil
+	}
+	return parseTypeSpec(s)
+}
 
 func typeSpecified(t ast.TypeSpec) bool {
-	return t.Item != nil || len(t.Name) > 0
+	return t.Item != nil || t.Func != nil || len(t.Name) > 0
 }
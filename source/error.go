@@ -3,6 +3,11 @@
 
 package source
 
+import (
+	"fmt"
+	"strings"
+)
+
 type wrappedError struct {
 	msg string
 	err error
@@ -27,3 +32,38 @@ func ErrorWithPositionPrefix(err error, fallback string) error {
 
 	return err
 }
+
+// ErrorList contains multiple errors.  Position-aware errors are formatted on
+// separate lines by PositionError.
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", list[0].Error(), len(list)-1)
+	}
+}
+
+func (list ErrorList) PositionError() string {
+	lines := make([]string, len(list))
+	for i, err := range list {
+		lines[i] = ErrorWithPositionPrefix(err, "").Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (list ErrorList) Unwrap() []error {
+	return list
+}
+
+// Err returns nil if the list is empty.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
@@ -34,22 +34,32 @@ type Kind int
 const (
 	_ Kind = iota
 
+	Illegal // Invalid input.
+
 	Space // Excluding newline.
 	Newline
-	Comment
+	Comment      // Line comment.
+	BlockComment // Nestable.
+	DocComment   // Line comment starting with exactly three slashes.
 
 	// Keywords
 	Auto
 	Break
+	Case
 	Clone
 	Continue
+	Default
+	Defer
 	Else
 	False
 	For
+	Func
 	If
 	Import
 	Nil
+	Range
 	Return
+	Switch
 	True
 
 	// Identifier, or keyword at file level or in type definition
@@ -58,6 +68,7 @@ const (
 
 	// Basic type literals
 	Integer   // 12345
+	Float     // 1.5
 	Character // 'a'
 	String    // "abc" or `abc`
 
@@ -91,6 +102,18 @@ const (
 	Assign // =
 	Define // :=
 
+	PlusAssign       // +=
+	MinusAssign      // -=
+	AsteriskAssign   // *=
+	SlashAssign      // /=
+	PercentAssign    // %=
+	AndNotAssign     // &^=
+	AmpersandAssign  // &=
+	PipeAssign       // |=
+	CaretAssign      // ^=
+	ShiftLeftAssign  // <<=
+	ShiftRightAssign // >>=
+
 	Comma     // ,
 	Period    // .
 	Semicolon // ;
@@ -116,26 +139,37 @@ func (k Kind) String() string {
 }
 
 var strings = [...]string{
-	Space:   "Space",
-	Newline: "Newline",
-	Comment: "Comment",
+	Illegal: "Illegal",
+
+	Space:        "Space",
+	Newline:      "Newline",
+	Comment:      "Comment",
+	BlockComment: "BlockComment",
+	DocComment:   "DocComment",
 
 	Auto:     "auto",
 	Break:    "break",
+	Case:     "case",
 	Clone:    "clone",
 	Continue: "continue",
+	Default:  "default",
+	Defer:    "defer",
 	Else:     "else",
 	False:    "false",
 	For:      "for",
+	Func:     "func",
 	If:       "if",
 	Nil:      "nil",
+	Range:    "range",
 	Return:   "return",
+	Switch:   "switch",
 	True:     "true",
 
 	WordLower: "WordLower",
 	WordUpper: "WordUpper",
 
 	Integer:   "Integer",
+	Float:     "Float",
 	Character: "Character",
 	String:    "String",
 
@@ -168,6 +202,18 @@ var strings = [...]string{
 	Assign: "=",
 	Define: ":=",
 
+	PlusAssign:       "+=",
+	MinusAssign:      "-=",
+	AsteriskAssign:   "*=",
+	SlashAssign:      "/=",
+	PercentAssign:    "%=",
+	AndNotAssign:     "&^=",
+	AmpersandAssign:  "&=",
+	PipeAssign:       "|=",
+	CaretAssign:      "^=",
+	ShiftLeftAssign:  "<<=",
+	ShiftRightAssign: ">>=",
+
 	Comma:     ",",
 	Period:    ".",
 	Semicolon: ";",